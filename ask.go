@@ -0,0 +1,194 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"tama/internal/config"
+	"tama/internal/ollama"
+	"tama/internal/tui"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/spf13/cobra"
+)
+
+// askOptions are the flags of the ask subcommand
+type askOptions struct {
+	Model   string
+	System  string
+	Render  bool
+	JSON    bool
+	Options ollama.Options
+	Think   ollama.Think
+	Config  config.Config // Gives the model's system prompt when --system is not
+}
+
+// askResult is the output of ask --json
+type askResult struct {
+	Model    string         `json:"model"`
+	Prompt   string         `json:"prompt"`
+	Response string         `json:"response"`
+	Thinking string         `json:"thinking,omitempty"`
+	Metrics  ollama.Metrics `json:"metrics,omitzero"`
+	Duration time.Duration  `json:"duration"`
+	Options  ollama.Options `json:"options,omitzero"`
+}
+
+var askOpts askOptions
+
+var askCmd = &cobra.Command{
+	Use:   "ask [prompt]",
+	Short: "Ask a single question and print the answer",
+	Long: `Ask sends one prompt to Ollama and streams the answer to stdout.
+
+Text piped on stdin is appended to the prompt as extra context, e.g.
+
+  git diff --staged | tama ask "Write a commit message for this diff"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		client := newClient(cfg)
+		askOpts.Config = cfg
+		askOpts.Options = cfg.Options.Merge(flagOptions(cmd))
+		askOpts.Think = cfg.Think
+		if optionFlags.think != "" {
+			if askOpts.Think, err = ollama.ParseThink(optionFlags.think); err != nil {
+				return err
+			}
+		}
+		var stdin io.Reader
+		if !isTerminal(os.Stdin) {
+			stdin = os.Stdin
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		return runAsk(ctx, client, askOpts, strings.Join(args, " "), stdin, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	askCmd.Flags().StringVarP(&askOpts.Model, "model", "m", "", "Model to use (default: the running model, else the last one used)")
+	askCmd.Flags().StringVarP(&askOpts.System, "system", "s", "", "System prompt")
+	askCmd.Flags().BoolVarP(&askOpts.Render, "render", "r", false, "Render the answer as markdown once it is complete")
+	askCmd.Flags().BoolVar(&askOpts.JSON, "json", false, "Print the answer as a JSON object once it is complete")
+	rootCmd.AddCommand(askCmd)
+}
+
+// runAsk sends prompt, followed by anything read from stdin, and writes the
+// answer to out. Raw answers are streamed as they arrive.
+func runAsk(ctx context.Context, client *ollama.Client, opts askOptions, prompt string, stdin io.Reader, out io.Writer) error {
+	if stdin != nil {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		if extra := strings.TrimSpace(string(data)); extra != "" {
+			prompt = strings.TrimSpace(prompt + "\n\n" + extra)
+		}
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return errors.New("no prompt given: pass it as an argument or on stdin")
+	}
+
+	model := opts.Model
+	if model == "" {
+		var err error
+		model, _, err = tui.ResolveModel(ctx, client)
+		if err != nil {
+			return err
+		}
+	}
+
+	// --system overrides the configured prompt, as in the interactive app
+	var messages []ollama.Message
+	if system := cmp.Or(opts.System, opts.Config.SystemPromptFor(model)); system != "" {
+		messages = append(messages, ollama.Message{Role: "system", Content: system})
+	}
+	messages = append(messages, ollama.Message{Role: "user", Content: prompt})
+
+	req := ollama.ChatRequest{Model: model, Messages: messages, Think: opts.Think}
+	if !opts.Options.IsZero() {
+		req.Options = &opts.Options
+	}
+
+	start := time.Now()
+	chunks, err := client.Chat(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	streaming := !opts.Render && !opts.JSON
+	// Reasoning is kept out of the streamed answer; --json includes it
+	var response, thinking strings.Builder
+	var metrics ollama.Metrics
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			if streaming {
+				fmt.Fprintln(out)
+			}
+			return chunk.Err
+		}
+		if chunk.Done {
+			metrics = chunk.Metrics
+		}
+		thinking.WriteString(chunk.Message.Thinking)
+		response.WriteString(chunk.Message.Content)
+		if streaming {
+			fmt.Fprint(out, chunk.Message.Content)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch {
+	case opts.JSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(askResult{
+			Model:    model,
+			Prompt:   prompt,
+			Response: response.String(),
+			Thinking: thinking.String(),
+			Duration: time.Since(start),
+			Options:  opts.Options,
+			Metrics:  metrics,
+		})
+	case opts.Render:
+		r, err := glamour.NewTermRenderer(
+			glamour.WithStandardStyle("tokyo-night"),
+			glamour.WithWordWrap(tui.ContentWidth),
+		)
+		if err != nil {
+			return err
+		}
+		rendered, err := r.Render(response.String())
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, rendered)
+	default:
+		if !strings.HasSuffix(response.String(), "\n") {
+			fmt.Fprintln(out)
+		}
+	}
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
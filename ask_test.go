@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tama/internal/config"
+	"tama/internal/ollama"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAskServer fakes Ollama, recording the chat request it receives
+func newAskServer(t *testing.T, received *ollama.ChatRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/ps":
+			fmt.Fprint(w, `{"models":[{"name":"running-model"}]}`)
+		case "/api/chat":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(received))
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hello"}}`)
+			fmt.Fprintln(w, `{"message":{"role":"assistant","content":" there"},"done":true}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAskStreamsAnswerWithRunningModel(t *testing.T) {
+	var received ollama.ChatRequest
+	server := newAskServer(t, &received)
+	var out bytes.Buffer
+
+	err := runAsk(context.Background(), ollama.NewClient(server.URL), askOptions{}, "Say hi", nil, &out)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Hello there\n", out.String())
+	assert.Equal(t, "running-model", received.Model, "Should use the running model like the TUI")
+	assert.Equal(t, []ollama.Message{{Role: "user", Content: "Say hi"}}, received.Messages)
+}
+
+func TestAskAppendsStdinAndSystemPrompt(t *testing.T) {
+	var received ollama.ChatRequest
+	server := newAskServer(t, &received)
+	opts := askOptions{Model: "llama3.2:3b", System: "Be terse."}
+
+	err := runAsk(context.Background(), ollama.NewClient(server.URL), opts, "Summarise:", strings.NewReader("diff --git a b\n"), &bytes.Buffer{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "llama3.2:3b", received.Model)
+	assert.Equal(t, []ollama.Message{
+		{Role: "system", Content: "Be terse."},
+		{Role: "user", Content: "Summarise:\n\ndiff --git a b"},
+	}, received.Messages)
+}
+
+func TestAskUsesConfiguredSystemPrompt(t *testing.T) {
+	var received ollama.ChatRequest
+	server := newAskServer(t, &received)
+	opts := askOptions{Config: config.Config{
+		SystemPrompt:  "Be helpful.",
+		SystemPrompts: map[string]string{"running-model": "Answer in French."},
+	}}
+
+	err := runAsk(context.Background(), ollama.NewClient(server.URL), opts, "Say hi", nil, &bytes.Buffer{})
+
+	require.NoError(t, err)
+	assert.Equal(t, ollama.Message{Role: "system", Content: "Answer in French."}, received.Messages[0], "The model's own prompt wins")
+
+	opts.System = "Be terse."
+	err = runAsk(context.Background(), ollama.NewClient(server.URL), opts, "Say hi", nil, &bytes.Buffer{})
+
+	require.NoError(t, err)
+	assert.Equal(t, ollama.Message{Role: "system", Content: "Be terse."}, received.Messages[0], "--system wins over the config")
+}
+
+func TestAskSendsOptions(t *testing.T) {
+	var received ollama.ChatRequest
+	server := newAskServer(t, &received)
+	opts := askOptions{Model: "m"}
+	require.NoError(t, opts.Options.Set("seed", "7"))
+
+	err := runAsk(context.Background(), ollama.NewClient(server.URL), opts, "Say hi", nil, &bytes.Buffer{})
+
+	require.NoError(t, err)
+	require.NotNil(t, received.Options)
+	assert.Equal(t, 7, *received.Options.Seed)
+}
+
+func TestAskJSONOutput(t *testing.T) {
+	var received ollama.ChatRequest
+	server := newAskServer(t, &received)
+	var out bytes.Buffer
+
+	err := runAsk(context.Background(), ollama.NewClient(server.URL), askOptions{Model: "m", JSON: true}, "Say hi", nil, &out)
+
+	require.NoError(t, err)
+	var result askResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, "m", result.Model)
+	assert.Equal(t, "Say hi", result.Prompt)
+	assert.Equal(t, "Hello there", result.Response)
+}
+
+func TestAskRequiresPrompt(t *testing.T) {
+	err := runAsk(context.Background(), ollama.NewClient("http://127.0.0.1:0"), askOptions{}, "", strings.NewReader("  \n"), &bytes.Buffer{})
+	assert.ErrorContains(t, err, "no prompt given")
+}
+
+func TestAskReturnsOllamaErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model 'nope' not found"}`)
+	}))
+	defer server.Close()
+
+	err := runAsk(context.Background(), ollama.NewClient(server.URL), askOptions{Model: "nope"}, "hi", nil, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "model 'nope' not found")
+}
+
+func TestAskReturnsStreamErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"}}`)
+		fmt.Fprintln(w, `{"error":"model runner has unexpectedly stopped"}`)
+	}))
+	defer server.Close()
+	var out bytes.Buffer
+
+	err := runAsk(context.Background(), ollama.NewClient(server.URL), askOptions{Model: "m"}, "hi", nil, &out)
+
+	assert.EqualError(t, err, "ollama: model runner has unexpectedly stopped")
+	assert.Equal(t, "Hel\n", out.String(), "The partial answer ends its line before the error is printed")
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"tama/internal/ollama"
+)
+
+// Config holds the persisted tama settings.
+type Config struct {
+	Host          string            `json:"host,omitempty"`           // Ollama server, in any form accepted by OLLAMA_HOST
+	SystemPrompt  string            `json:"system_prompt,omitempty"`  // Default system prompt for every model
+	SystemPrompts map[string]string `json:"system_prompts,omitempty"` // Default system prompt per model name
+	Options       ollama.Options    `json:"options,omitzero"`         // Default generation options
+	Think         ollama.Think      `json:"think,omitempty"`          // Default reasoning setting for thinking models
+	Context       ContextConfig     `json:"context,omitzero"`         // How long conversations are fitted into the context window
+	Runners       map[string]string `json:"runners,omitempty"`        // Command that runs code blocks per language, given the code on stdin
+	Input         InputConfig       `json:"input,omitzero"`           // Keys and size of the prompt
+}
+
+// Context window strategies, applied when a conversation no longer fits in
+// the model's context.
+const (
+	StrategyDropOldest = "drop-oldest" // Drop the oldest pairs until it fits
+	StrategyKeepFirst  = "keep-first"  // Keep the first pairs, drop the oldest after them
+	StrategySliding    = "sliding"     // Send only the most recent pairs, dropping more if needed
+	StrategyNone       = "none"        // Send everything and let Ollama truncate
+)
+
+// ContextConfig chooses the context window strategy.
+type ContextConfig struct {
+	Strategy  string `json:"strategy,omitempty"`   // One of the Strategy constants (default drop-oldest)
+	KeepFirst int    `json:"keep_first,omitempty"` // Pairs kept by keep-first (default 1)
+	Window    int    `json:"window,omitempty"`     // Pairs sent by sliding (default 10)
+	CompactAt int    `json:"compact_at,omitempty"` // Context usage percent that triggers /compact (0 disables)
+}
+
+// StrategyOrDefault returns the configured strategy, or drop-oldest.
+func (c ContextConfig) StrategyOrDefault() string {
+	if c.Strategy == "" {
+		return StrategyDropOldest
+	}
+	return c.Strategy
+}
+
+// KeepFirstOrDefault returns the number of leading pairs keep-first keeps.
+func (c ContextConfig) KeepFirstOrDefault() int {
+	if c.KeepFirst <= 0 {
+		return 1
+	}
+	return c.KeepFirst
+}
+
+// WindowOrDefault returns the number of recent pairs sliding sends.
+func (c ContextConfig) WindowOrDefault() int {
+	if c.Window <= 0 {
+		return 10
+	}
+	return c.Window
+}
+
+func (c ContextConfig) validate() error {
+	if c.CompactAt < 0 || c.CompactAt > 100 {
+		return fmt.Errorf("context compact_at must be a percentage, not %d", c.CompactAt)
+	}
+	switch c.StrategyOrDefault() {
+	case StrategyDropOldest, StrategyKeepFirst, StrategySliding, StrategyNone:
+		return nil
+	}
+	return fmt.Errorf("unknown context strategy %q (use %s, %s, %s or %s)",
+		c.Strategy, StrategyDropOldest, StrategyKeepFirst, StrategySliding, StrategyNone)
+}
+
+// InputConfig chooses the keys that send the prompt or break its line, named
+// as Bubble Tea names them (e.g. "enter", "alt+enter", "ctrl+j"), and how
+// tall the prompt may grow before it scrolls.
+type InputConfig struct {
+	Submit    []string `json:"submit,omitempty"`     // Keys sending the prompt (default enter)
+	Newline   []string `json:"newline,omitempty"`    // Keys inserting a newline (default alt+enter and ctrl+j)
+	MaxHeight int      `json:"max_height,omitempty"` // Rows shown before the prompt scrolls (default 10)
+	History   string   `json:"history,omitempty"`    // Prompts recalled: HistoryGlobal (default) or HistorySession
+}
+
+// Prompt history scopes: which earlier prompts Up, Down and Ctrl+R recall.
+const (
+	HistoryGlobal  = "global"  // Prompts sent in every session
+	HistorySession = "session" // Prompts sent in the current session
+)
+
+// SubmitKeys returns the keys that send the prompt.
+func (c InputConfig) SubmitKeys() []string {
+	if len(c.Submit) == 0 {
+		return []string{"enter"}
+	}
+	return c.Submit
+}
+
+// NewlineKeys returns the keys that insert a newline into the prompt.
+func (c InputConfig) NewlineKeys() []string {
+	if len(c.Newline) == 0 {
+		return []string{"alt+enter", "ctrl+j"}
+	}
+	return c.Newline
+}
+
+// MaxHeightOrDefault returns the rows the prompt shows before scrolling.
+func (c InputConfig) MaxHeightOrDefault() int {
+	if c.MaxHeight <= 0 {
+		return 10
+	}
+	return c.MaxHeight
+}
+
+// HistoryIsGlobal reports whether prompts from every session are recalled.
+func (c InputConfig) HistoryIsGlobal() bool {
+	return c.History != HistorySession
+}
+
+func (c InputConfig) validate() error {
+	if c.History != "" && c.History != HistoryGlobal && c.History != HistorySession {
+		return fmt.Errorf("unknown input history %q (use %s or %s)", c.History, HistoryGlobal, HistorySession)
+	}
+	if c.MaxHeight < 0 {
+		return fmt.Errorf("input max_height must not be negative, not %d", c.MaxHeight)
+	}
+	for _, key := range c.NewlineKeys() {
+		if slices.Contains(c.SubmitKeys(), key) {
+			return fmt.Errorf("input key %q both submits and inserts a newline", key)
+		}
+	}
+	return nil
+}
+
+// defaultRunners run code blocks in languages whose interpreter reads the
+// program from stdin.
+var defaultRunners = map[string]string{
+	"sh":         "sh",
+	"shell":      "sh",
+	"bash":       "bash",
+	"zsh":        "zsh",
+	"python":     "python3",
+	"python3":    "python3",
+	"py":         "python3",
+	"javascript": "node",
+	"js":         "node",
+	"ruby":       "ruby",
+	"rb":         "ruby",
+}
+
+// RunnerFor returns the command that runs code blocks in lang: its entry in
+// runners, else a default for common scripting languages, else "".
+func (c Config) RunnerFor(lang string) string {
+	lang = strings.ToLower(lang)
+	if command, ok := c.Runners[lang]; ok {
+		return command
+	}
+	return defaultRunners[lang]
+}
+
+// Dir returns the tama config directory under XDG_CONFIG_HOME.
+func Dir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, _ := os.UserHomeDir()
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "tama")
+}
+
+// DataDir returns the tama data directory under XDG_DATA_HOME.
+func DataDir() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, _ := os.UserHomeDir()
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "tama")
+}
+
+// Path returns the location of the config file.
+func Path() string {
+	return filepath.Join(Dir(), "config.json")
+}
+
+// Load reads the config file. A missing file yields an empty Config.
+func Load() (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(Path())
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", Path(), err)
+	}
+	if err := cfg.Context.validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", Path(), err)
+	}
+	if err := cfg.Input.validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", Path(), err)
+	}
+	return cfg, nil
+}
+
+// ResolveHost picks the Ollama host by precedence: the --host flag, then
+// OLLAMA_HOST, then the config file, then the default local server.
+func (c Config) ResolveHost(flagHost string) string {
+	if flagHost != "" {
+		return flagHost
+	}
+	if env := os.Getenv("OLLAMA_HOST"); env != "" {
+		return env
+	}
+	return c.Host
+}
+
+// SystemPromptFor returns the default system prompt for a model: its own
+// entry in system_prompts, else system_prompt.
+func (c Config) SystemPromptFor(model string) string {
+	if prompt, ok := c.SystemPrompts[model]; ok {
+		return prompt
+	}
+	return c.SystemPrompt
+}
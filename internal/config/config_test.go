@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMissingConfigIsEmpty(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, Config{}, cfg)
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tama"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tama", "config.json"), []byte(`{"host":"gpu-box:11434"}`), 0644))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "gpu-box:11434", cfg.Host)
+}
+
+func TestLoadInvalidConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tama"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tama", "config.json"), []byte(`{host`), 0644))
+
+	_, err := Load()
+	assert.ErrorContains(t, err, "invalid config file")
+}
+
+func TestLoadRejectsUnknownContextStrategy(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tama"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tama", "config.json"), []byte(`{"context":{"strategy":"drop-newest"}}`), 0644))
+
+	_, err := Load()
+	assert.ErrorContains(t, err, `unknown context strategy "drop-newest"`)
+}
+
+func TestContextConfigDefaults(t *testing.T) {
+	var c ContextConfig
+	assert.Equal(t, StrategyDropOldest, c.StrategyOrDefault())
+	assert.Equal(t, 1, c.KeepFirstOrDefault())
+	assert.Equal(t, 10, c.WindowOrDefault())
+}
+
+func TestLoadRejectsConflictingInputKeys(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tama"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tama", "config.json"), []byte(`{"input":{"newline":["enter"]}}`), 0644))
+
+	_, err := Load()
+	assert.ErrorContains(t, err, `input key "enter" both submits and inserts a newline`)
+}
+
+func TestInputConfigDefaults(t *testing.T) {
+	var c InputConfig
+	assert.Equal(t, []string{"enter"}, c.SubmitKeys())
+	assert.Equal(t, []string{"alt+enter", "ctrl+j"}, c.NewlineKeys())
+	assert.Equal(t, 10, c.MaxHeightOrDefault())
+	assert.True(t, c.HistoryIsGlobal())
+	assert.False(t, InputConfig{History: HistorySession}.HistoryIsGlobal())
+	assert.ErrorContains(t, InputConfig{History: "project"}.validate(), `unknown input history "project"`)
+
+	swapped := InputConfig{Submit: []string{"alt+enter"}, Newline: []string{"enter"}}
+	assert.NoError(t, swapped.validate())
+}
+
+func TestResolveHostPrecedence(t *testing.T) {
+	cfg := Config{Host: "config-box"}
+
+	t.Setenv("OLLAMA_HOST", "")
+	assert.Equal(t, "config-box", cfg.ResolveHost(""), "Config file should be used when nothing else is set")
+
+	t.Setenv("OLLAMA_HOST", "env-box")
+	assert.Equal(t, "env-box", cfg.ResolveHost(""), "OLLAMA_HOST should override the config file")
+	assert.Equal(t, "flag-box", cfg.ResolveHost("flag-box"), "--host should override everything")
+
+	t.Setenv("OLLAMA_HOST", "")
+	assert.Equal(t, "", Config{}.ResolveHost(""), "Empty result means the default host")
+}
+
+func TestSystemPromptFor(t *testing.T) {
+	cfg := Config{
+		SystemPrompt:  "Be helpful.",
+		SystemPrompts: map[string]string{"qwen3:8b": "Answer in English.", "plain": ""},
+	}
+
+	assert.Equal(t, "Answer in English.", cfg.SystemPromptFor("qwen3:8b"), "Per-model entry should win")
+	assert.Equal(t, "Be helpful.", cfg.SystemPromptFor("llama3.2:3b"), "Other models use the global default")
+	assert.Equal(t, "", cfg.SystemPromptFor("plain"), "An empty per-model entry disables the default")
+}
+
+func TestRunnerFor(t *testing.T) {
+	cfg := Config{Runners: map[string]string{"go": "gorun", "bash": ""}}
+
+	assert.Equal(t, "gorun", cfg.RunnerFor("Go"), "Configured runners match any case")
+	assert.Equal(t, "python3", cfg.RunnerFor("python"), "Common scripting languages have a default")
+	assert.Equal(t, "", cfg.RunnerFor("bash"), "An empty entry disables the default")
+	assert.Equal(t, "", cfg.RunnerFor("rust"))
+}
@@ -0,0 +1,173 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tama/internal/config"
+)
+
+// MaxEntries is how many prompts the history keeps; older ones are dropped.
+const MaxEntries = 1000
+
+// trimSlack is how many prompts past MaxEntries the file may hold before
+// it is trimmed, so it is rewritten only once in a while
+const trimSlack = MaxEntries / 10
+
+// Entry is a prompt that was sent.
+type Entry struct {
+	Text    string    `json:"text"`
+	Session string    `json:"session,omitempty"` // ID of the session it was sent in
+	At      time.Time `json:"at"`
+}
+
+// History holds the prompts sent, oldest first, kept in a file of one JSON
+// entry per line so each prompt is appended without rewriting the rest.
+// Several tama instances can share the file: each only appends to it, and
+// re-reads it before trimming it.
+type History struct {
+	Path    string
+	Entries []Entry
+	excess  int // Entries in the file before the MaxEntries kept
+}
+
+// DefaultPath is where the history is kept inside the tama data directory.
+func DefaultPath() string {
+	return filepath.Join(config.DataDir(), "history.jsonl")
+}
+
+// Load reads the history in path. A missing file yields an empty history,
+// and lines that cannot be read are skipped. The History is usable even
+// when an error is returned.
+func Load(path string) (*History, error) {
+	h := &History{Path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil || entry.Text == "" {
+			continue
+		}
+		h.Entries = append(h.Entries, entry)
+	}
+	if len(h.Entries) > MaxEntries {
+		h.excess = len(h.Entries) - MaxEntries
+		h.Entries = h.Entries[h.excess:]
+	}
+	return h, nil
+}
+
+// Add records text as sent in the session with the given ID. Sending the
+// same prompt twice in a row records it once.
+func (h *History) Add(text, sessionID string) error {
+	if text == "" {
+		return nil
+	}
+	if n := len(h.Entries); n > 0 && h.Entries[n-1].Text == text && h.Entries[n-1].Session == sessionID {
+		return nil
+	}
+	entry := Entry{Text: text, Session: sessionID, At: time.Now()}
+	h.Entries = append(h.Entries, entry)
+	if len(h.Entries) > MaxEntries {
+		h.Entries = h.Entries[1:]
+		h.excess++
+	}
+	if err := h.append(entry); err != nil {
+		return err
+	}
+	if h.excess >= trimSlack {
+		return h.trim()
+	}
+	return nil
+}
+
+func (h *History) append(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.Path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// trim rewrites the file with only the newest MaxEntries, read again so the
+// prompts other instances appended are kept
+func (h *History) trim() error {
+	latest, err := Load(h.Path)
+	if err != nil {
+		return err
+	}
+	h.Entries = latest.Entries
+	h.excess = 0
+	return h.rewrite()
+}
+
+// rewrite replaces the file with the entries kept, atomically as sessions
+// are saved
+func (h *History) rewrite() error {
+	var data bytes.Buffer
+	for _, entry := range h.Entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history entry: %w", err)
+		}
+		data.Write(append(line, '\n'))
+	}
+
+	dir := filepath.Dir(h.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(h.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), h.Path)
+}
+
+// Prompts returns the prompts sent in the session with the given ID, or in
+// every session if it is "", newest first and each only once.
+func (h *History) Prompts(sessionID string) []string {
+	var prompts []string
+	seen := map[string]bool{}
+	for i := len(h.Entries) - 1; i >= 0; i-- {
+		entry := h.Entries[i]
+		if sessionID != "" && entry.Session != sessionID {
+			continue
+		}
+		if seen[entry.Text] {
+			continue
+		}
+		seen[entry.Text] = true
+		prompts = append(prompts, entry.Text)
+	}
+	return prompts
+}
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAndLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tama", "history.jsonl")
+	h, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, h.Entries, "A missing file is an empty history")
+
+	require.NoError(t, h.Add("What is Go?", "s1"))
+	require.NoError(t, h.Add("Explain\nchannels", "s2"))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Entries, 2)
+	assert.Equal(t, "What is Go?", loaded.Entries[0].Text)
+	assert.Equal(t, "s1", loaded.Entries[0].Session)
+	assert.Equal(t, "Explain\nchannels", loaded.Entries[1].Text)
+}
+
+func TestAddSkipsRepeatedPrompt(t *testing.T) {
+	h := &History{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+
+	require.NoError(t, h.Add("again", "s1"))
+	require.NoError(t, h.Add("again", "s1"))
+	require.NoError(t, h.Add("", "s1"))
+
+	assert.Len(t, h.Entries, 1)
+}
+
+func TestLoadSkipsUnreadableLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"text":"one"}`+"\n{broken\n"+`{"text":"two"}`+"\n"), 0644))
+
+	h, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"two", "one"}, h.Prompts(""))
+}
+
+func TestAddDropsOldestBeyondMaxEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h := &History{Path: path}
+	for i := range MaxEntries + 5 {
+		require.NoError(t, h.Add(fmt.Sprintf("prompt %d", i), ""))
+	}
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Entries, MaxEntries)
+	assert.Equal(t, "prompt 5", loaded.Entries[0].Text)
+	assert.Equal(t, fmt.Sprintf("prompt %d", MaxEntries+4), loaded.Entries[MaxEntries-1].Text)
+}
+
+func TestPromptsAreNewestFirstAndDeduplicated(t *testing.T) {
+	h := &History{Entries: []Entry{
+		{Text: "a", Session: "s1"},
+		{Text: "b", Session: "s2"},
+		{Text: "a", Session: "s2"},
+		{Text: "c", Session: "s1"},
+	}}
+
+	assert.Equal(t, []string{"c", "a", "b"}, h.Prompts(""))
+	assert.Equal(t, []string{"c", "a"}, h.Prompts("s1"))
+	assert.Equal(t, []string{"a", "b"}, h.Prompts("s2"))
+	assert.Empty(t, h.Prompts("s3"))
+}
+
+func TestTrimKeepsPromptsOfOtherInstances(t *testing.T) {
+	// Given a full history shared by two instances
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	h := &History{Path: path}
+	for i := range MaxEntries {
+		require.NoError(t, h.Add(fmt.Sprintf("prompt %d", i), ""))
+	}
+	one, err := Load(path)
+	require.NoError(t, err)
+	two, err := Load(path)
+	require.NoError(t, err)
+
+	// When one adds a prompt and the other adds enough to trim the file
+	require.NoError(t, two.Add("from two", ""))
+	for i := range trimSlack {
+		require.NoError(t, one.Add(fmt.Sprintf("from one %d", i), ""))
+	}
+
+	// Then the file is trimmed with the prompt of the other kept
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, MaxEntries, bytes.Count(data, []byte("\n")))
+	assert.Contains(t, one.Prompts(""), "from two")
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Contains(t, loaded.Prompts(""), "from two")
+	assert.Equal(t, fmt.Sprintf("from one %d", trimSlack-1), loaded.Entries[MaxEntries-1].Text)
+}
@@ -0,0 +1,176 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultBaseURL is the address of a stock local Ollama server.
+const DefaultBaseURL = "http://localhost:11434"
+
+// Client talks to the Ollama HTTP API rooted at BaseURL.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client for the Ollama server at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{},
+	}
+}
+
+// StatusError is returned when Ollama answers with a non-200 status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Chat sends a chat request and streams the response chunks on the returned
+// channel. The channel is closed when the response is done, the stream
+// fails or ctx is cancelled; a failed stream ends with a chunk whose Err is
+// set. Callers that stop reading early must cancel ctx.
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (<-chan Chunk, error) {
+	req.Stream = true
+	resp, err := c.do(ctx, http.MethodPost, "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+	return stream(ctx, resp.Body,
+		func(chunk Chunk) bool { return chunk.Done },
+		func(err error) Chunk { return Chunk{Err: err} },
+	), nil
+}
+
+// ListRunning returns the models currently loaded into memory (/api/ps).
+func (c *Client) ListRunning(ctx context.Context) ([]RunningModel, error) {
+	var body struct {
+		Models []RunningModel `json:"models"`
+	}
+	if err := c.getJSON(ctx, "/api/ps", &body); err != nil {
+		return nil, err
+	}
+	return body.Models, nil
+}
+
+// ListLocal returns the models installed on the server (/api/tags).
+func (c *Client) ListLocal(ctx context.Context) ([]LocalModel, error) {
+	var body struct {
+		Models []LocalModel `json:"models"`
+	}
+	if err := c.getJSON(ctx, "/api/tags", &body); err != nil {
+		return nil, err
+	}
+	return body.Models, nil
+}
+
+// Show returns details about a single model (/api/show).
+func (c *Client) Show(ctx context.Context, model string) (*ShowResponse, error) {
+	resp, err := c.do(ctx, http.MethodPost, "/api/show", map[string]string{"model": model})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var show ShowResponse
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &show, nil
+}
+
+// Pull downloads a model, streaming progress updates on the returned
+// channel until the pull succeeds, fails or ctx is cancelled; a failed pull
+// ends with an update whose Err is set.
+func (c *Client) Pull(ctx context.Context, model string) (<-chan PullProgress, error) {
+	resp, err := c.do(ctx, http.MethodPost, "/api/pull", map[string]any{"model": model, "stream": true})
+	if err != nil {
+		return nil, err
+	}
+	return stream(ctx, resp.Body,
+		func(progress PullProgress) bool { return progress.Status == "success" },
+		func(err error) PullProgress { return PullProgress{Err: err} },
+	), nil
+}
+
+// Unload asks the server to evict a model from memory immediately.
+func (c *Client) Unload(ctx context.Context, model string) error {
+	resp, err := c.do(ctx, http.MethodPost, "/api/generate", map[string]any{"model": model, "keep_alive": 0})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// do sends a request with an optional JSON body and returns the response if
+// the server answered 200 OK. The caller must close the response body.
+func (c *Client) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			statusErr.Message = apiErr.Error
+		}
+		return nil, statusErr
+	}
+
+	return resp, nil
+}
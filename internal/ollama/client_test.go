@@ -0,0 +1,330 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatStreamsChunks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/chat", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req ChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-model", req.Model)
+		assert.True(t, req.Stream, "Chat should always request a stream")
+		assert.Equal(t, []Message{{Role: "user", Content: "hi"}}, req.Messages)
+
+		fmt.Fprintln(w, `{"model":"test-model","message":{"role":"assistant","content":"Hello"}}`)
+		fmt.Fprintln(w, `{"model":"test-model","message":{"role":"assistant","content":" world"}}`)
+		fmt.Fprintln(w, `{"model":"test-model","message":{"role":"assistant","content":""},"done":true}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	chunks, err := client.Chat(context.Background(), ChatRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: "user", Content: "hi"}},
+	})
+	require.NoError(t, err)
+
+	var content string
+	var count int
+	var done bool
+	for chunk := range chunks {
+		content += chunk.Message.Content
+		done = chunk.Done
+		count++
+	}
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "Hello world", content)
+	assert.True(t, done, "Last chunk should be marked done")
+}
+
+func TestChatFinalChunkMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hi"}}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"length",`+
+			`"total_duration":3000000000,"load_duration":1500000000,"prompt_eval_count":26,`+
+			`"prompt_eval_duration":130000000,"eval_count":290,"eval_duration":1450000000}`)
+	}))
+	defer server.Close()
+
+	chunks, err := NewClient(server.URL).Chat(context.Background(), ChatRequest{Model: "m"})
+	require.NoError(t, err)
+
+	var last Chunk
+	for chunk := range chunks {
+		last = chunk
+	}
+	assert.Equal(t, 290, last.EvalCount)
+	assert.Equal(t, 26, last.PromptEvalCount)
+	assert.Equal(t, 1500*time.Millisecond, last.LoadDuration)
+	assert.InDelta(t, 200, last.TokensPerSecond(), 0.001)
+	assert.True(t, last.Truncated())
+}
+
+func TestChatReturnsStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model 'nope' not found"}`)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Chat(context.Background(), ChatRequest{Model: "nope"})
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, "model 'nope' not found", statusErr.Message)
+}
+
+func TestChatStopsWhenContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"partial"}}`)
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := NewClient(server.URL).Chat(ctx, ChatRequest{Model: "test-model"})
+	require.NoError(t, err)
+
+	first := <-chunks
+	assert.Equal(t, "partial", first.Message.Content)
+
+	cancel()
+	for range chunks {
+	}
+}
+
+func TestChatDecodesLinesLongerThanScannerBuffer(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q}}`+"\n", long)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true}`)
+	}))
+	defer server.Close()
+
+	chunks, err := NewClient(server.URL).Chat(context.Background(), ChatRequest{Model: "m"})
+	require.NoError(t, err)
+
+	var content string
+	for chunk := range chunks {
+		require.NoError(t, chunk.Err)
+		content += chunk.Message.Content
+	}
+	assert.Equal(t, long, content)
+}
+
+func TestChatReportsStreamFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		assert func(t *testing.T, err error)
+	}{
+		{
+			name: "error object from Ollama",
+			body: `{"message":{"role":"assistant","content":"Hi"}}` + "\n" + `{"error":"model runner has unexpectedly stopped"}` + "\n",
+			assert: func(t *testing.T, err error) {
+				var streamErr *StreamError
+				require.ErrorAs(t, err, &streamErr)
+				assert.Equal(t, "model runner has unexpectedly stopped", streamErr.Message)
+			},
+		},
+		{
+			name: "stream ends before done",
+			body: `{"message":{"role":"assistant","content":"Hi"}}` + "\n",
+			assert: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrIncomplete)
+			},
+		},
+		{
+			name: "line cut off",
+			body: `{"message":{"role":"assistant","content":"Hi"}}` + "\n" + `{"message":{"ro`,
+			assert: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrIncomplete)
+			},
+		},
+		{
+			name: "invalid JSON",
+			body: `{"message":{"role":"assistant","content":"Hi"}}` + "\n" + "<html>oops</html>\n",
+			assert: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "invalid response from Ollama")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			chunks, err := NewClient(server.URL).Chat(context.Background(), ChatRequest{Model: "m"})
+			require.NoError(t, err)
+
+			// Given a stream that fails after one chunk
+			var got []Chunk
+			for chunk := range chunks {
+				got = append(got, chunk)
+			}
+
+			// Then the content arrives, followed by a chunk with the error
+			require.Len(t, got, 2)
+			assert.Equal(t, "Hi", got[0].Message.Content)
+			assert.NoError(t, got[0].Err)
+			tt.assert(t, got[1].Err)
+		})
+	}
+}
+
+func TestChatReleasesStreamWhenConsumerStops(t *testing.T) {
+	closed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for {
+			if _, err := fmt.Fprintln(w, `{"message":{"role":"assistant","content":"more"}}`); err != nil {
+				close(closed)
+				return
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := NewClient(server.URL).Chat(ctx, ChatRequest{Model: "m"})
+	require.NoError(t, err)
+
+	// Given a consumer that stops reading after the first chunk
+	<-chunks
+
+	// When it cancels the request
+	cancel()
+
+	// Then the connection is closed and the channel too, with no error
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server kept writing after the request was cancelled")
+	}
+	for chunk := range chunks {
+		assert.NoError(t, chunk.Err)
+	}
+}
+
+func TestListRunning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/ps", r.URL.Path)
+		fmt.Fprint(w, `{"models":[{"name":"gpt-oss:20b","size":13000000000,"size_vram":13000000000}]}`)
+	}))
+	defer server.Close()
+
+	models, err := NewClient(server.URL).ListRunning(context.Background())
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+	assert.Equal(t, "gpt-oss:20b", models[0].Name)
+	assert.Equal(t, int64(13000000000), models[0].SizeVRAM)
+}
+
+func TestListLocal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tags", r.URL.Path)
+		fmt.Fprint(w, `{"models":[{"name":"llama3.2:3b","size":2019393189,"details":{"family":"llama","parameter_size":"3.2B","quantization_level":"Q4_K_M"}}]}`)
+	}))
+	defer server.Close()
+
+	models, err := NewClient(server.URL).ListLocal(context.Background())
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+	assert.Equal(t, "llama3.2:3b", models[0].Name)
+	assert.Equal(t, "llama", models[0].Details.Family)
+	assert.Equal(t, "Q4_K_M", models[0].Details.QuantizationLevel)
+}
+
+func TestShow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/show", r.URL.Path)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "llama3.2:3b", body["model"])
+		fmt.Fprint(w, `{"parameters":"num_ctx 8192","details":{"family":"llama"},"model_info":{"llama.context_length":131072},"capabilities":["completion"]}`)
+	}))
+	defer server.Close()
+
+	show, err := NewClient(server.URL).Show(context.Background(), "llama3.2:3b")
+	require.NoError(t, err)
+	assert.Equal(t, "num_ctx 8192", show.Parameters)
+	assert.Equal(t, 8192, show.NumCtx())
+	assert.Equal(t, "llama", show.Details.Family)
+	assert.Equal(t, float64(131072), show.ModelInfo["llama.context_length"])
+	assert.Equal(t, []string{"completion"}, show.Capabilities)
+}
+
+func TestPull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/pull", r.URL.Path)
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"status":"downloading","digest":"sha256:abc","total":100,"completed":50}`)
+		fmt.Fprintln(w, `{"status":"success"}`)
+	}))
+	defer server.Close()
+
+	progress, err := NewClient(server.URL).Pull(context.Background(), "llama3.2:3b")
+	require.NoError(t, err)
+
+	var statuses []string
+	for p := range progress {
+		statuses = append(statuses, p.Status)
+	}
+	assert.Equal(t, []string{"pulling manifest", "downloading", "success"}, statuses)
+}
+
+func TestPullReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"status":"pulling manifest"}`)
+		fmt.Fprintln(w, `{"error":"pull model manifest: file does not exist"}`)
+	}))
+	defer server.Close()
+
+	progress, err := NewClient(server.URL).Pull(context.Background(), "nope")
+	require.NoError(t, err)
+
+	var last PullProgress
+	for p := range progress {
+		last = p
+	}
+	assert.EqualError(t, last.Err, "ollama: pull model manifest: file does not exist")
+}
+
+func TestUnload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "llama3.2:3b", body["model"])
+		assert.Equal(t, float64(0), body["keep_alive"])
+		fmt.Fprint(w, `{"model":"llama3.2:3b","done":true,"done_reason":"unload"}`)
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL).Unload(context.Background(), "llama3.2:3b")
+	assert.NoError(t, err)
+}
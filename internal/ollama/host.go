@@ -0,0 +1,53 @@
+package ollama
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseHost turns a host specification in any of the forms accepted by
+// OLLAMA_HOST ("0.0.0.0", ":8080", "example.com:11434",
+// "https://example.com/ollama", ...) into a base URL for NewClient. An empty
+// string yields DefaultBaseURL.
+func ParseHost(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return DefaultBaseURL
+	}
+
+	defaultPort := "11434"
+	scheme, hostport, ok := strings.Cut(s, "://")
+	switch {
+	case !ok:
+		scheme, hostport = "http", s
+	case scheme == "http":
+		defaultPort = "80"
+	case scheme == "https":
+		defaultPort = "443"
+	}
+
+	hostport, path, _ := strings.Cut(hostport, "/")
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = "localhost", defaultPort
+		if ip := net.ParseIP(strings.Trim(hostport, "[]")); ip != nil {
+			host = ip.String()
+		} else if hostport != "" {
+			host = hostport
+		}
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		port = defaultPort
+	}
+
+	u := url.URL{Scheme: scheme, Host: net.JoinHostPort(host, port)}
+	if path != "" {
+		u.Path = "/" + strings.TrimRight(path, "/")
+	}
+	return u.String()
+}
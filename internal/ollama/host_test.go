@@ -0,0 +1,32 @@
+package ollama
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		expected string
+	}{
+		{"Empty uses default", "", "http://localhost:11434"},
+		{"Bare IP", "0.0.0.0", "http://0.0.0.0:11434"},
+		{"Port only", ":8080", "http://localhost:8080"},
+		{"Host and port", "gpu-box:11434", "http://gpu-box:11434"},
+		{"Bare hostname", "gpu-box", "http://gpu-box:11434"},
+		{"HTTP URL without port", "http://gpu-box", "http://gpu-box:80"},
+		{"HTTPS URL without port", "https://ollama.example.com", "https://ollama.example.com:443"},
+		{"URL with path", "https://example.com:8443/ollama/", "https://example.com:8443/ollama"},
+		{"IPv6 with port", "[::1]:11434", "http://[::1]:11434"},
+		{"Invalid port falls back", "gpu-box:99999", "http://gpu-box:11434"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseHost(tt.host))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package ollama
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Options are the model parameters sent in a request's options block. Unset
+// fields are omitted so the model's own defaults apply.
+type Options struct {
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	TopK          *int     `json:"top_k,omitempty"`
+	MinP          *float64 `json:"min_p,omitempty"`
+	NumCtx        *int     `json:"num_ctx,omitempty"`
+	NumPredict    *int     `json:"num_predict,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+	Seed          *int     `json:"seed,omitempty"`
+	Stop          []string `json:"stop,omitempty"`
+}
+
+// OptionNames lists the option names accepted by Set, in declaration order.
+func OptionNames() []string {
+	t := reflect.TypeOf(Options{})
+	names := make([]string, t.NumField())
+	for i := range names {
+		names[i] = optionName(t.Field(i))
+	}
+	return names
+}
+
+func optionName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// field returns the settable struct field for an option name.
+func (o *Options) field(name string) (reflect.Value, error) {
+	v := reflect.ValueOf(o).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if optionName(v.Type().Field(i)) == name {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown option %q (options: %s)", name, strings.Join(OptionNames(), ", "))
+}
+
+// Set parses value into the named option. An empty value unsets it. Stop
+// sequences are comma separated.
+func (o *Options) Set(name, value string) error {
+	f, err := o.field(name)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+
+	switch f.Type() {
+	case reflect.TypeOf((*float64)(nil)):
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number", name)
+		}
+		f.Set(reflect.ValueOf(&n))
+	case reflect.TypeOf((*int)(nil)):
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", name)
+		}
+		f.Set(reflect.ValueOf(&n))
+	case reflect.TypeOf([]string(nil)):
+		f.Set(reflect.ValueOf(strings.Split(value, ",")))
+	}
+	return nil
+}
+
+// IsZero reports whether no option is set.
+func (o Options) IsZero() bool {
+	return reflect.ValueOf(o).IsZero()
+}
+
+// Merge returns o with every option set in override replacing its own.
+func (o Options) Merge(override Options) Options {
+	merged := reflect.ValueOf(&o).Elem()
+	over := reflect.ValueOf(override)
+	for i := 0; i < over.NumField(); i++ {
+		if !over.Field(i).IsZero() {
+			merged.Field(i).Set(over.Field(i))
+		}
+	}
+	return o
+}
+
+// String lists the set options as name=value pairs.
+func (o Options) String() string {
+	v := reflect.ValueOf(o)
+	var parts []string
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.IsZero() {
+			continue
+		}
+		var value string
+		if f.Kind() == reflect.Slice {
+			value = strconv.Quote(strings.Join(f.Interface().([]string), ","))
+		} else {
+			value = fmt.Sprint(f.Elem().Interface())
+		}
+		parts = append(parts, optionName(v.Type().Field(i))+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
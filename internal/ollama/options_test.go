@@ -0,0 +1,60 @@
+package ollama
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsSet(t *testing.T) {
+	var opts Options
+
+	require.NoError(t, opts.Set("temperature", "0.2"))
+	require.NoError(t, opts.Set("num_ctx", "8192"))
+	require.NoError(t, opts.Set("stop", "</s>,User:"))
+
+	assert.Equal(t, 0.2, *opts.Temperature)
+	assert.Equal(t, 8192, *opts.NumCtx)
+	assert.Equal(t, []string{"</s>", "User:"}, opts.Stop)
+
+	require.NoError(t, opts.Set("temperature", ""))
+	assert.Nil(t, opts.Temperature, "An empty value unsets the option")
+}
+
+func TestOptionsSetRejectsBadInput(t *testing.T) {
+	var opts Options
+
+	assert.ErrorContains(t, opts.Set("temprature", "1"), `unknown option "temprature"`)
+	assert.ErrorContains(t, opts.Set("seed", "1.5"), "seed must be a whole number")
+	assert.ErrorContains(t, opts.Set("top_p", "high"), "top_p must be a number")
+	assert.True(t, opts.IsZero())
+}
+
+func TestOptionsMergeAndString(t *testing.T) {
+	var defaults, session Options
+	require.NoError(t, defaults.Set("temperature", "0.8"))
+	require.NoError(t, defaults.Set("num_ctx", "4096"))
+	require.NoError(t, session.Set("temperature", "0"))
+	require.NoError(t, session.Set("seed", "42"))
+
+	merged := defaults.Merge(session)
+
+	assert.Equal(t, "temperature=0 num_ctx=4096 seed=42", merged.String())
+	assert.Equal(t, 0.8, *defaults.Temperature, "Merge leaves the receiver unchanged")
+	assert.Equal(t, "", Options{}.String())
+}
+
+func TestChatRequestOmitsUnsetOptions(t *testing.T) {
+	var opts Options
+	require.NoError(t, opts.Set("temperature", "0"))
+
+	data, err := json.Marshal(ChatRequest{Model: "m", Options: &opts})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model":"m","messages":null,"stream":false,"options":{"temperature":0}}`, string(data))
+
+	data, err = json.Marshal(ChatRequest{Model: "m"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "options")
+}
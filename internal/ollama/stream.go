@@ -0,0 +1,87 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrIncomplete is reported when a stream ends before Ollama marked the
+// response done, usually because the connection dropped.
+var ErrIncomplete = errors.New("the response ended before it was done; the connection may have dropped")
+
+// StreamError is an error Ollama reported in the middle of a stream, after
+// the 200 status was sent, e.g. when the model runner crashes.
+type StreamError struct {
+	Message string
+}
+
+func (e *StreamError) Error() string {
+	return "ollama: " + e.Message
+}
+
+// stream decodes the newline-delimited JSON objects in body, of any size,
+// sending each on the returned channel until done reports the last one.
+// If the stream fails, a final value made by failed carries the error:
+// Ollama's own {"error": ...} objects, undecodable lines, read errors and
+// an end of stream before done. Nothing is reported once ctx is cancelled.
+// The channel is closed and body closed when the stream ends or ctx is
+// cancelled, so a consumer that stops reading early must cancel ctx.
+func stream[T any](ctx context.Context, body io.ReadCloser, done func(T) bool, failed func(error) T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		defer body.Close()
+
+		send := func(v T) bool {
+			select {
+			case out <- v:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		fail := func(err error) {
+			if ctx.Err() == nil {
+				send(failed(err))
+			}
+		}
+
+		dec := json.NewDecoder(body)
+		for {
+			var line json.RawMessage
+			if err := dec.Decode(&line); err != nil {
+				var syntaxErr *json.SyntaxError
+				switch {
+				case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+					fail(ErrIncomplete)
+				case errors.As(err, &syntaxErr):
+					fail(fmt.Errorf("invalid response from Ollama: %w", err))
+				default:
+					fail(fmt.Errorf("failed to read response: %w", err))
+				}
+				return
+			}
+
+			var apiErr struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal(line, &apiErr) == nil && apiErr.Error != "" {
+				fail(&StreamError{Message: apiErr.Error})
+				return
+			}
+
+			var v T
+			if err := json.Unmarshal(line, &v); err != nil {
+				fail(fmt.Errorf("invalid response from Ollama: %w", err))
+				return
+			}
+			if !send(v) || done(v) {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,51 @@
+package ollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Think controls the reasoning of thinking models. Ollama takes a boolean, or
+// a level for models such as gpt-oss; the empty value leaves the model's
+// default.
+type Think string
+
+// ParseThink accepts true, false, on, off, low, medium or high.
+func ParseThink(s string) (Think, error) {
+	switch strings.ToLower(s) {
+	case "true", "on":
+		return "true", nil
+	case "false", "off":
+		return "false", nil
+	case "low", "medium", "high":
+		return Think(strings.ToLower(s)), nil
+	}
+	return "", fmt.Errorf("think must be true, false, low, medium or high, not %q", s)
+}
+
+// MarshalJSON writes true and false as booleans and levels as strings.
+func (t Think) MarshalJSON() ([]byte, error) {
+	if t == "true" || t == "false" {
+		return []byte(t), nil
+	}
+	return json.Marshal(string(t))
+}
+
+// UnmarshalJSON reads a boolean or a level.
+func (t *Think) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	if s == "" {
+		*t = ""
+		return nil
+	}
+	parsed, err := ParseThink(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
@@ -0,0 +1,40 @@
+package ollama
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThink(t *testing.T) {
+	for input, want := range map[string]Think{"on": "true", "False": "false", "HIGH": "high", "low": "low"} {
+		got, err := ParseThink(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := ParseThink("extreme")
+	assert.ErrorContains(t, err, "think must be true, false, low, medium or high")
+}
+
+func TestThinkJSON(t *testing.T) {
+	data, err := json.Marshal(ChatRequest{Model: "m", Think: "false"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"think":false`, "Booleans are sent as JSON booleans")
+
+	data, err = json.Marshal(ChatRequest{Model: "m", Think: "high"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"think":"high"`)
+
+	data, err = json.Marshal(ChatRequest{Model: "m"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "think", "Unset leaves the model default")
+
+	var cfg struct{ A, B Think }
+	require.NoError(t, json.Unmarshal([]byte(`{"A": true, "B": "medium"}`), &cfg))
+	assert.Equal(t, Think("true"), cfg.A)
+	assert.Equal(t, Think("medium"), cfg.B)
+	assert.Error(t, json.Unmarshal([]byte(`{"A": 3}`), &cfg))
+}
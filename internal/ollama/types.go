@@ -0,0 +1,122 @@
+package ollama
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a single chat message.
+type Message struct {
+	Role     string `json:"role"`
+	Content  string `json:"content"`
+	Thinking string `json:"thinking,omitempty"` // Reasoning streamed by thinking models
+}
+
+// ChatRequest is the body of a /api/chat request.
+type ChatRequest struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream"`
+	Options  *Options  `json:"options,omitempty"`
+	Think    Think     `json:"think,omitempty"`
+}
+
+// Chunk is one line of a streamed /api/chat response. Metrics are only set
+// on the final chunk.
+type Chunk struct {
+	Model     string  `json:"model"`
+	CreatedAt string  `json:"created_at"`
+	Message   Message `json:"message"`
+	Done      bool    `json:"done"`
+	Metrics
+	Err error `json:"-"` // Why the stream failed, set on its last chunk
+}
+
+// Metrics are the token counts and timings reported when a response is
+// done. Durations are sent in nanoseconds, so decode as time.Duration.
+type Metrics struct {
+	DoneReason         string        `json:"done_reason,omitempty"` // "stop", or "length" when cut off by num_predict or num_ctx
+	TotalDuration      time.Duration `json:"total_duration,omitempty"`
+	LoadDuration       time.Duration `json:"load_duration,omitempty"`
+	PromptEvalCount    int           `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration time.Duration `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int           `json:"eval_count,omitempty"`
+	EvalDuration       time.Duration `json:"eval_duration,omitempty"`
+}
+
+// TokensPerSecond is the generation speed, or 0 if unknown.
+func (m Metrics) TokensPerSecond() float64 {
+	if m.EvalDuration <= 0 {
+		return 0
+	}
+	return float64(m.EvalCount) / m.EvalDuration.Seconds()
+}
+
+// Truncated reports whether the response stopped at a length limit rather
+// than finishing.
+func (m Metrics) Truncated() bool {
+	return m.DoneReason == "length"
+}
+
+// ModelDetails describes the format and size of a model.
+type ModelDetails struct {
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// LocalModel is a model installed on the server, as listed by /api/tags.
+type LocalModel struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// RunningModel is a model loaded into memory, as listed by /api/ps.
+type RunningModel struct {
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Size      int64        `json:"size"`
+	SizeVRAM  int64        `json:"size_vram"`
+	Digest    string       `json:"digest"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Details   ModelDetails `json:"details"`
+}
+
+// ShowResponse is the body of a /api/show response.
+type ShowResponse struct {
+	Modelfile    string         `json:"modelfile"`
+	Parameters   string         `json:"parameters"`
+	Template     string         `json:"template"`
+	Details      ModelDetails   `json:"details"`
+	ModelInfo    map[string]any `json:"model_info"`
+	Capabilities []string       `json:"capabilities"`
+}
+
+// NumCtx returns the num_ctx parameter set in the model's Modelfile, or 0
+// if it uses the server default.
+func (s ShowResponse) NumCtx() int {
+	for _, line := range strings.Split(s.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			n, _ := strconv.Atoi(fields[1])
+			return n
+		}
+	}
+	return 0
+}
+
+// PullProgress is one status update of a streamed /api/pull response.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Err       error  `json:"-"` // Why the pull failed, set on its last update
+}
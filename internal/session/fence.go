@@ -0,0 +1,75 @@
+package session
+
+import "strings"
+
+// FenceLine is what a line of markdown is to its fenced code blocks.
+type FenceLine int
+
+const (
+	Text    FenceLine = iota // Outside any fenced block
+	Opening                  // The fence opening a block
+	Code                     // Inside a block
+	Closing                  // The fence closing a block
+)
+
+// Fences follows the fenced code blocks of markdown line by line. A block
+// opens with a line of three or more backticks or tildes indented by at
+// most three spaces, and closes with a line of at least as many of the same
+// character and nothing else. A block left open runs to the end of the text.
+type Fences struct {
+	fence  string // Opening fence of the block the last line was in, if any
+	indent int    // Spaces before the opening fence
+	info   string // Text after the opening fence
+}
+
+// Scan reports what line is, given the lines scanned before it.
+func (f *Fences) Scan(line string) FenceLine {
+	trimmed := strings.TrimLeft(line, " ")
+	spaces := len(line) - len(trimmed)
+	marker := ""
+	if spaces <= 3 {
+		marker = fenceMarker(trimmed)
+	}
+	if f.fence == "" {
+		if marker == "" {
+			return Text
+		}
+		f.fence, f.indent = marker, spaces
+		f.info = strings.TrimSpace(trimmed[len(marker):])
+		return Opening
+	}
+	if marker != "" && marker[0] == f.fence[0] && len(marker) >= len(f.fence) && strings.TrimSpace(trimmed[len(marker):]) == "" {
+		f.fence = ""
+		return Closing
+	}
+	return Code
+}
+
+// Open reports whether the last line scanned was inside a block that is
+// still open.
+func (f *Fences) Open() bool {
+	return f.fence != ""
+}
+
+// Info returns the text after the fence of the block the last line opened
+// or was in, e.g. "go" or "python title=x.py".
+func (f *Fences) Info() string {
+	return f.info
+}
+
+// Indent returns how many spaces the fence of the block the last line
+// opened or was in is indented by.
+func (f *Fences) Indent() int {
+	return f.indent
+}
+
+// fenceMarker returns the run of three or more backticks or tildes that
+// starts line, if there is one
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if n := len(line) - len(strings.TrimLeft(line, c)); n >= 3 {
+			return line[:n]
+		}
+	}
+	return ""
+}
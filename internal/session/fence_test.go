@@ -0,0 +1,25 @@
+package session
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFencesScan(t *testing.T) {
+	var f Fences
+	var got []FenceLine
+	for _, line := range strings.Split("text\n  ````go title=x\n```\n    ````\ncode\n````\n~~~\n!ls", "\n") {
+		got = append(got, f.Scan(line))
+		if line == "code" {
+			assert.Equal(t, "go title=x", f.Info())
+			assert.Equal(t, 2, f.Indent())
+		}
+	}
+
+	// A shorter or indented fence does not close the block, and the last
+	// one is left open
+	assert.Equal(t, []FenceLine{Text, Opening, Code, Code, Code, Closing, Opening, Code}, got)
+	assert.True(t, f.Open())
+}
@@ -0,0 +1,144 @@
+package session
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Match is a pair of a saved session that contains a search query. The
+// pair may be on a branch other than the active one, or match through a
+// response other than the active one.
+type Match struct {
+	Session  *Session
+	Pair     int      // Index of the matching pair in the conversation, once Branches are taken
+	Branches []Branch // Branches to switch to, in order, to bring the pair into Session.Pairs
+	Response int      // Position of the matching response among all of the pair's responses
+	Model    string   // Model that produced the matching response
+	Snippet  string   // Text around the first match, on one line
+}
+
+// Branch picks the branch at position Branch among those diverging at
+// pair Pair.
+type Branch struct {
+	Pair   int
+	Branch int
+}
+
+// Other describes where the match is when it is not on the active path
+// with the active response: "other branch", "other response" or "".
+func (m Match) Other() string {
+	if len(m.Branches) > 0 {
+		return "other branch"
+	}
+	if m.Response != m.Session.Pairs[m.Pair].Selected {
+		return "other response"
+	}
+	return ""
+}
+
+// Search returns the pairs of every saved session whose request and
+// response contain each word of query, ignoring case. Sessions are ordered
+// newest first and pairs in conversation order, each followed by the
+// matches on the branches diverging at it. Responses replaced by
+// regenerating are searched as well.
+func (st *Store) Search(query string) ([]Match, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+	sessions, err := st.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, s := range sessions {
+		matches = searchPath(matches, s, s.Pairs, 0, nil, words)
+	}
+	return matches, nil
+}
+
+// searchPath appends the matches in pairs, the path from pair start of s
+// reached by taking branches, to matches
+func searchPath(matches []Match, s *Session, pairs []Pair, start int, branches []Branch, words []string) []Match {
+	for j, pair := range pairs {
+		i := start + j
+		all := pair.Responses()
+		for n := range all {
+			// The active response first: when it matches, the others would
+			// only repeat a match on the request
+			n = (pair.Selected + n) % len(all)
+			at, text := find(pair.Request+" "+all[n].Response, words)
+			if at < 0 {
+				continue
+			}
+			matches = append(matches, Match{
+				Session:  s,
+				Pair:     i,
+				Branches: branches,
+				Response: n,
+				Model:    all[n].Model,
+				Snippet:  snippet(text, at),
+			})
+			if n == pair.Selected {
+				break
+			}
+		}
+		for k, sibling := range pair.Siblings {
+			// The active branch sits at position Branch among the siblings
+			position := k
+			if k >= pair.Branch {
+				position++
+			}
+			taken := append(slices.Clone(branches), Branch{Pair: i, Branch: position})
+			matches = searchPath(matches, s, sibling, i, taken, words)
+		}
+	}
+	return matches
+}
+
+// find returns the byte offset in text, flattened onto one line, of the
+// first of words it contains, or -1 unless it contains them all
+func find(text string, words []string) (int, string) {
+	text = strings.Join(strings.Fields(text), " ")
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowering changed byte offsets; show the lowered text
+		text = lower
+	}
+	first := -1
+	for _, w := range words {
+		at := strings.Index(lower, w)
+		if at < 0 {
+			return -1, text
+		}
+		if first < 0 || at < first {
+			first = at
+		}
+	}
+	return first, text
+}
+
+// snippet cuts the text around byte offset at, marking what was left out
+func snippet(text string, at int) string {
+	const before, after = 30, 70
+	start := at
+	for n := 0; n < before && start > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	end := at
+	for n := 0; n < after && end < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	cut := text[start:end]
+	if start > 0 {
+		cut = "…" + cut
+	}
+	if end < len(text) {
+		cut += "…"
+	}
+	return cut
+}
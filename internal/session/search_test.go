@@ -0,0 +1,131 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchFindsPairsAcrossSessions(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	// Given two saved sessions that mention goroutines
+	older := New()
+	older.UpdatedAt = time.Now().Add(-time.Hour)
+	older.Pairs = []Pair{
+		{Request: "What is a goroutine?", Response: "A lightweight thread."},
+		{Request: "And channels?", Response: "They connect goroutines."},
+	}
+	newer := New()
+	newer.Pairs = []Pair{
+		{Request: "Hi", Response: "Hello"},
+		{Request: "Are goroutines cheap?", Response: "Yes, very."},
+	}
+	require.NoError(t, store.Save(older))
+	require.NoError(t, store.Save(newer))
+
+	// When searching for "Goroutine"
+	matches, err := store.Search("Goroutine")
+	require.NoError(t, err)
+
+	// Then every matching pair is found, newest session first
+	require.Len(t, matches, 3)
+	assert.Equal(t, newer.ID, matches[0].Session.ID)
+	assert.Equal(t, 1, matches[0].Pair)
+	assert.Equal(t, older.ID, matches[1].Session.ID)
+	assert.Equal(t, 0, matches[1].Pair)
+	assert.Equal(t, 1, matches[2].Pair)
+	assert.Equal(t, "And channels? They connect goroutines.", matches[2].Snippet)
+}
+
+func TestSearchRequiresEveryWord(t *testing.T) {
+	store := NewStore(t.TempDir())
+	s := New()
+	s.Pairs = []Pair{
+		{Request: "Rust or Go?", Response: "Both compile to native code."},
+		{Request: "Is Go fast?", Response: "Fast enough."},
+	}
+	require.NoError(t, store.Save(s))
+
+	matches, err := store.Search("go native")
+	require.NoError(t, err)
+
+	require.Len(t, matches, 1)
+	assert.Equal(t, 0, matches[0].Pair)
+}
+
+func TestSearchSnippetIsCutAroundMatch(t *testing.T) {
+	store := NewStore(t.TempDir())
+	s := New()
+	s.Pairs = []Pair{{
+		Request:  "Explain",
+		Response: strings.Repeat("filler ", 20) + "the needle\nis here " + strings.Repeat("more ", 30),
+	}}
+	require.NoError(t, store.Save(s))
+
+	matches, err := store.Search("needle")
+	require.NoError(t, err)
+
+	require.Len(t, matches, 1)
+	snippet := matches[0].Snippet
+	assert.True(t, strings.HasPrefix(snippet, "…"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+	assert.Contains(t, snippet, "the needle is here", "Line breaks are flattened")
+}
+
+// branchedSession has a branch left by editing its second request and a
+// response replaced by regenerating its third
+func branchedSession() *Session {
+	s := New()
+	s.Pairs = []Pair{
+		{Request: "Hi", Response: "Hello"},
+		{
+			Request: "What is Go?", Response: "A language",
+			Branch: 1,
+			Siblings: [][]Pair{{
+				{Request: "What is Rust?", Response: "A language with borrowing"},
+				{Request: "Any goroutines?", Response: "No, threads"},
+			}},
+		},
+		{
+			Request: "Explain", Response: "Goroutines are cheap",
+			Alternatives: []Alternative{{Response: "Guard it with a mutex", Model: "qwen3:8b"}},
+		},
+	}
+	return s
+}
+
+func TestSearchFindsOtherBranchesAndResponses(t *testing.T) {
+	store := NewStore(t.TempDir())
+	s := branchedSession()
+	require.NoError(t, store.Save(s))
+
+	// A match on the branch is listed after the pair it diverges at
+	matches, err := store.Search("goroutines")
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, 2, matches[0].Pair)
+	assert.Equal(t, []Branch{{Pair: 1, Branch: 0}}, matches[0].Branches)
+	assert.Equal(t, "Any goroutines? No, threads", matches[0].Snippet)
+	assert.Equal(t, "other branch", matches[0].Other())
+	assert.Equal(t, 2, matches[1].Pair)
+	assert.Empty(t, matches[1].Branches)
+	assert.Empty(t, matches[1].Other())
+
+	// A match in a replaced response names its position
+	matches, err = store.Search("mutex")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 1, matches[0].Response)
+	assert.Equal(t, "qwen3:8b", matches[0].Model)
+	assert.Equal(t, "other response", matches[0].Other())
+
+	// A match on the request alone is listed once
+	matches, err = store.Search("explain")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, 0, matches[0].Response)
+}
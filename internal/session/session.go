@@ -0,0 +1,291 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"tama/internal/ollama"
+)
+
+// Version is the session file format written by this build. Files with a
+// newer version are refused rather than silently losing data on save.
+const Version = 1
+
+// Pair is one request and the response it produced. A conversation whose
+// requests were edited is a tree: Pairs holds the active path, and the pair
+// where branches diverge holds the others in Siblings.
+type Pair struct {
+	Request      string         `json:"request"`
+	Response     string         `json:"response"`
+	Model        string         `json:"model,omitempty"` // Model that produced the response
+	Duration     time.Duration  `json:"duration"`        // Time taken to generate the response
+	Cancelled    bool           `json:"cancelled,omitempty"`
+	Error        string         `json:"error,omitempty"` // Why the response failed, keeping any partial text
+	RequestedAt  time.Time      `json:"requested_at,omitzero"`
+	Thinking     string         `json:"thinking,omitempty"`     // Reasoning streamed before the response
+	Summarized   bool           `json:"summarized,omitempty"`   // Replaced in requests by the session summary
+	Siblings     [][]Pair       `json:"siblings,omitempty"`     // Other branches diverging here, each the path from this point
+	Branch       int            `json:"branch,omitempty"`       // Position of this branch among its siblings
+	Alternatives []Alternative  `json:"alternatives,omitempty"` // Other responses to this request, from regenerating it
+	Selected     int            `json:"selected,omitempty"`     // Position of the active response among all of them
+	Options      ollama.Options `json:"options,omitzero"`       // Generation options the request was sent with
+	Metrics      ollama.Metrics `json:"metrics,omitzero"`       // Token counts and timings reported by Ollama
+	Attachments  []Attachment   `json:"attachments,omitempty"`  // Files referenced in the request with @path
+	Commands     []Command      `json:"commands,omitempty"`     // Commands run for the request's ! lines, with their output
+}
+
+// Attachment is a file referenced in a request with @path, kept as it was
+// when the request was sent so later requests send the same context.
+type Attachment struct {
+	Path    string `json:"path"`           // As written after the @
+	Lang    string `json:"lang,omitempty"` // Language tag for the fenced block
+	Content string `json:"content"`
+}
+
+// Command is a command run for a "!command" line of a request, and what it
+// printed, kept so the request can be resent and the output reviewed.
+type Command struct {
+	Command string `json:"command"`
+	Output  string `json:"output"`          // Standard output and error, interleaved
+	Error   string `json:"error,omitempty"` // How the command failed, e.g. "exit status 1"
+}
+
+// commandLine returns the command of a "!command" line of a request. Lines
+// starting "!!", an escaped "!", or "![", a markdown image, are not commands.
+func commandLine(line string) (string, bool) {
+	if !strings.HasPrefix(line, "!") || strings.HasPrefix(line, "!!") || strings.HasPrefix(line, "![") {
+		return "", false
+	}
+	command := strings.TrimSpace(line[1:])
+	return command, command != ""
+}
+
+// Commands returns the commands of the "!command" lines of a request,
+// skipping those inside fenced code blocks
+func Commands(request string) []string {
+	var commands []string
+	var f Fences
+	for _, line := range strings.Split(request, "\n") {
+		if f.Scan(line) != Text {
+			// Code such as "!pip install" in a notebook cell
+			continue
+		}
+		if command, ok := commandLine(line); ok {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// block shows the command and its output as a fenced block, followed by how
+// it failed if it did
+func (c Command) block() string {
+	body := "$ " + c.Command
+	if output := strings.TrimRight(c.Output, "\n"); output != "" {
+		body += "\n" + output
+	}
+	fence := codeFence(body)
+	block := fence + "console\n" + body + "\n" + fence
+	if c.Error != "" {
+		block += "\n(" + c.Error + ")"
+	}
+	return block
+}
+
+// Prompt returns the request as sent to the model: its text with each line
+// of a command run replaced by the command's output, followed by the
+// contents of each attachment, all in fenced blocks. Lines inside fenced
+// blocks of the request are sent as written; elsewhere the escapes "!!"
+// and "@@" are sent as a single "!" or "@".
+func (p Pair) Prompt() string {
+	var b strings.Builder
+	var f Fences
+	next := 0 // Command run for the next command line
+	for i, line := range strings.Split(p.Request, "\n") {
+		code := f.Scan(line) != Text
+		if command, ok := commandLine(line); ok && !code && next < len(p.Commands) && command == p.Commands[next].Command {
+			line = p.Commands[next].block()
+			next++
+		} else if !code {
+			// An escaped "!" starting a line that is not a command
+			if strings.HasPrefix(line, "!!") {
+				line = line[1:]
+			}
+			line = unescapeRefs(line)
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+	}
+	for _, a := range p.Attachments {
+		fence := codeFence(a.Content)
+		fmt.Fprintf(&b, "\n\nFile: %s\n%s%s\n%s\n%s", a.Path, fence, a.Lang, strings.TrimRight(a.Content, "\n"), fence)
+	}
+	return b.String()
+}
+
+// escapedRef matches the "@@" starting a word that is not an attachment
+var escapedRef = regexp.MustCompile("(^|[\\s(`])@@")
+
+// unescapeRefs turns each escaped "@@" back into the "@" it stands for
+func unescapeRefs(line string) string {
+	return escapedRef.ReplaceAllString(line, "${1}@")
+}
+
+// codeFence returns a backtick fence longer than any run of backticks in
+// content, so the content cannot close it early
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(longest+1, 3))
+}
+
+// Alternative is one response to a request. The active response lives in
+// the Pair's own fields; a regenerated pair keeps the rest in Alternatives.
+type Alternative struct {
+	Response    string         `json:"response"`
+	Thinking    string         `json:"thinking,omitempty"`
+	Model       string         `json:"model,omitempty"`
+	Duration    time.Duration  `json:"duration"`
+	Cancelled   bool           `json:"cancelled,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	RequestedAt time.Time      `json:"requested_at,omitzero"`
+	Options     ollama.Options `json:"options,omitzero"`
+	Metrics     ollama.Metrics `json:"metrics,omitzero"`
+}
+
+// Active returns the pair's active response.
+func (p Pair) Active() Alternative {
+	return Alternative{
+		Response:    p.Response,
+		Thinking:    p.Thinking,
+		Model:       p.Model,
+		Duration:    p.Duration,
+		Cancelled:   p.Cancelled,
+		Error:       p.Error,
+		RequestedAt: p.RequestedAt,
+		Options:     p.Options,
+		Metrics:     p.Metrics,
+	}
+}
+
+// Responses returns every response to the request in order, the active
+// one included.
+func (p Pair) Responses() []Alternative {
+	all := slices.Clone(p.Alternatives)
+	return slices.Insert(all, min(p.Selected, len(all)), p.Active())
+}
+
+// SetActive makes a the pair's active response.
+func (p *Pair) SetActive(a Alternative) {
+	p.Response = a.Response
+	p.Thinking = a.Thinking
+	p.Model = a.Model
+	p.Duration = a.Duration
+	p.Cancelled = a.Cancelled
+	p.Error = a.Error
+	p.RequestedAt = a.RequestedAt
+	p.Options = a.Options
+	p.Metrics = a.Metrics
+}
+
+// Session is a saved conversation.
+type Session struct {
+	Version      int            `json:"version"`
+	ID           string         `json:"id"`
+	Name         string         `json:"name,omitempty"`          // Title given with /save
+	SystemPrompt string         `json:"system_prompt,omitempty"` // Set with /system or --system
+	Options      ollama.Options `json:"options,omitzero"`        // Set with /set or option flags
+	Think        ollama.Think   `json:"think,omitempty"`         // Set with /set think or --think
+	Summary      string         `json:"summary,omitempty"`       // Replaces the summarized pairs in requests, set by /compact
+	Model        string         `json:"model"`                   // Model in use when the session was last saved
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Pairs        []Pair         `json:"pairs"`
+}
+
+// New starts an empty session with a fresh ID.
+func New() *Session {
+	now := time.Now()
+	return &Session{
+		Version:   Version,
+		ID:        newID(now),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Title is the session's name, or a summary of its first request.
+func (s *Session) Title() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if len(s.Pairs) == 0 {
+		return "(empty)"
+	}
+	title := strings.Join(strings.Fields(s.Pairs[0].Request), " ")
+	const maxTitle = 60
+	if runes := []rune(title); len(runes) > maxTitle {
+		title = string(runes[:maxTitle-1]) + "…"
+	}
+	return title
+}
+
+// Markdown renders the conversation as a markdown transcript.
+func (s *Session) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", s.Title())
+	if s.Summary != "" {
+		fmt.Fprintf(&b, "\n## Summary\n\n%s\n", s.Summary)
+	}
+	for i, pair := range s.Pairs {
+		fmt.Fprintf(&b, "\n## Request %d\n\n%s\n", i+1, strings.TrimSpace(pair.Prompt()))
+
+		var details []string
+		if pair.Model != "" {
+			details = append(details, pair.Model)
+		}
+		if pair.Summarized {
+			details = append(details, "summarized")
+		}
+		if pair.Cancelled {
+			details = append(details, "cancelled")
+		} else if pair.Error != "" {
+			details = append(details, "failed: "+pair.Error)
+		} else if pair.Duration > 0 {
+			details = append(details, fmt.Sprintf("%.1fs", pair.Duration.Seconds()))
+		}
+		if pair.Metrics.EvalCount > 0 {
+			details = append(details, fmt.Sprintf("%d tokens", pair.Metrics.EvalCount))
+		}
+		heading := fmt.Sprintf("Response %d", i+1)
+		if len(details) > 0 {
+			heading += " (" + strings.Join(details, ", ") + ")"
+		}
+		fmt.Fprintf(&b, "\n## %s\n", heading)
+		if pair.Response != "" {
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(pair.Response))
+		}
+	}
+	return b.String()
+}
+
+// newID derives a sortable, collision-resistant ID from the creation time.
+func newID(t time.Time) string {
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return t.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
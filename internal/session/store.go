@@ -0,0 +1,146 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tama/internal/config"
+)
+
+// ErrNotFound is returned when a session does not exist.
+var ErrNotFound = errors.New("session not found")
+
+// Store reads and writes session files in a directory, one JSON file per
+// session.
+type Store struct {
+	Dir string
+}
+
+// NewStore returns a Store for the sessions in dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// DefaultDir is where sessions are kept inside the tama data directory.
+func DefaultDir() string {
+	return filepath.Join(config.DataDir(), "sessions")
+}
+
+func (st *Store) path(id string) string {
+	return filepath.Join(st.Dir, id+".json")
+}
+
+// checkID rejects IDs that would name a file outside the store, such as
+// "../../x" given to --resume.
+func checkID(id string) error {
+	if id == "" || id == "." || strings.Contains(id, "..") || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid session ID %q", id)
+	}
+	return nil
+}
+
+// Save writes the session atomically, so a crash mid-write never leaves a
+// truncated file behind.
+func (st *Store) Save(s *Session) error {
+	if err := checkID(s.ID); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(st.Dir, 0755); err != nil {
+		return err
+	}
+	s.Version = Version
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(st.Dir, s.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), st.path(s.ID))
+}
+
+// Load reads the session with the given ID.
+func (st *Store) Load(id string) (*Session, error) {
+	if err := checkID(id); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(st.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(data, id)
+}
+
+// List returns every readable session, most recently updated first.
+func (st *Store) List() ([]*Session, error) {
+	entries, err := os.ReadDir(st.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*Session
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		s, err := st.Load(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			// Skip files we cannot read rather than hiding every session
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Latest returns the most recently updated session.
+func (st *Store) Latest() (*Session, error) {
+	sessions, err := st.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, ErrNotFound
+	}
+	return sessions[0], nil
+}
+
+func decode(data []byte, id string) (*Session, error) {
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid session %s: %w", id, err)
+	}
+	if s.Version == 0 {
+		return nil, fmt.Errorf("invalid session %s: missing version", id)
+	}
+	if s.Version > Version {
+		return nil, fmt.Errorf("session %s has version %d; this tama supports up to %d", id, s.Version, Version)
+	}
+	return &s, nil
+}
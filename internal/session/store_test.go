@@ -0,0 +1,193 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	s := New()
+	s.Model = "gpt-oss:20b"
+	s.Pairs = []Pair{
+		{Request: "What is Go?", Response: "A language", Model: "gpt-oss:20b", Duration: 2500 * time.Millisecond},
+		{Request: "Tell me more", Cancelled: true},
+	}
+	require.NoError(t, store.Save(s))
+
+	loaded, err := store.Load(s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Version, loaded.Version)
+	assert.Equal(t, s.ID, loaded.ID)
+	assert.Equal(t, "gpt-oss:20b", loaded.Model)
+	require.Len(t, loaded.Pairs, 2)
+	assert.Equal(t, 2500*time.Millisecond, loaded.Pairs[0].Duration)
+	assert.True(t, loaded.Pairs[1].Cancelled)
+}
+
+func TestLoadMissingSession(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	_, err := store.Load("nope")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLoadRejectsPathsOutsideTheStore(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.json"), []byte(`{"version": 1, "id": "secret"}`), 0644))
+	store := NewStore(filepath.Join(dir, "sessions"))
+
+	for _, id := range []string{"../secret", "..", "a/b", `a\b`, ""} {
+		_, err := store.Load(id)
+		assert.ErrorContains(t, err, "invalid session ID", id)
+	}
+	assert.ErrorContains(t, store.Save(&Session{ID: "../secret"}), "invalid session ID")
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "future.json"), []byte(`{"version": 99, "id": "future"}`), 0644))
+
+	_, err := NewStore(dir).Load("future")
+	assert.ErrorContains(t, err, "version 99")
+}
+
+func TestListNewestFirstAndSkipsBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+
+	older := New()
+	older.UpdatedAt = time.Now().Add(-time.Hour)
+	older.Pairs = []Pair{{Request: "older"}}
+	newer := New()
+	newer.Pairs = []Pair{{Request: "newer"}}
+	require.NoError(t, store.Save(older))
+	require.NoError(t, store.Save(newer))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{`), 0644))
+
+	sessions, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "newer", sessions[0].Title())
+	assert.Equal(t, "older", sessions[1].Title())
+
+	latest, err := store.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, newer.ID, latest.ID)
+}
+
+func TestLatestWithNoSessions(t *testing.T) {
+	_, err := NewStore(filepath.Join(t.TempDir(), "missing")).Latest()
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestTitleUsesFirstRequest(t *testing.T) {
+	s := New()
+	assert.Equal(t, "(empty)", s.Title())
+
+	s.Pairs = []Pair{{Request: "  How do\nI reverse   a slice?  "}}
+	assert.Equal(t, "How do I reverse a slice?", s.Title())
+}
+
+func TestTitlePrefersName(t *testing.T) {
+	s := New()
+	s.Pairs = []Pair{{Request: "How do I reverse a slice?"}}
+	s.Name = "slices"
+	assert.Equal(t, "slices", s.Title())
+}
+
+func TestMarkdownTranscript(t *testing.T) {
+	s := New()
+	s.Name = "Go questions"
+	s.Pairs = []Pair{
+		{Request: "What is Go?", Response: "A language.", Model: "gpt-oss:20b", Duration: 2500 * time.Millisecond},
+		{Request: "Tell me more", Cancelled: true},
+	}
+
+	assert.Equal(t, `# Go questions
+
+## Request 1
+
+What is Go?
+
+## Response 1 (gpt-oss:20b, 2.5s)
+
+A language.
+
+## Request 2
+
+Tell me more
+
+## Response 2 (cancelled)
+`, s.Markdown())
+}
+
+func TestPromptAppendsAttachments(t *testing.T) {
+	pair := Pair{
+		Request: "Review @main.go and @README.md",
+		Attachments: []Attachment{
+			{Path: "main.go", Lang: "go", Content: "package main\n"},
+			{Path: "README.md", Lang: "markdown", Content: "```sh\ngo test\n```"},
+		},
+	}
+
+	assert.Equal(t, "Review @main.go and @README.md\n\n"+
+		"File: main.go\n```go\npackage main\n```\n\n"+
+		"File: README.md\n````markdown\n```sh\ngo test\n```\n````", pair.Prompt())
+	assert.Equal(t, "Hi", Pair{Request: "Hi"}.Prompt())
+}
+
+func TestPromptReplacesCommandLinesWithOutput(t *testing.T) {
+	pair := Pair{
+		Request: "Explain this diff\n!git diff --staged\n!!important: be brief\n![logo](logo.png)\n!false",
+		Commands: []Command{
+			{Command: "git diff --staged", Output: "+added line\n"},
+			{Command: "false", Error: "exit status 1"},
+		},
+	}
+
+	assert.Equal(t, "Explain this diff\n"+
+		"```console\n$ git diff --staged\n+added line\n```\n"+
+		"!important: be brief\n"+
+		"![logo](logo.png)\n"+
+		"```console\n$ false\n```\n(exit status 1)", pair.Prompt())
+}
+
+func TestPromptLeavesFencedCodeAsWritten(t *testing.T) {
+	pair := Pair{
+		Request:  "Why does this cell fail?\n```python\n!pip install numpy\n!!x and y\n```\n!!x\n~~~~\n!ls\n~~~\n~~~~\n!ls",
+		Commands: []Command{{Command: "ls", Output: "main.go\n"}},
+	}
+
+	assert.Equal(t, []string{"ls"}, Commands(pair.Request))
+	assert.Equal(t, "Why does this cell fail?\n```python\n!pip install numpy\n!!x and y\n```\n!x\n~~~~\n!ls\n~~~\n~~~~\n"+
+		"```console\n$ ls\nmain.go\n```", pair.Prompt())
+}
+
+func TestPromptUnescapesRefsOutsideCode(t *testing.T) {
+	pair := Pair{Request: "Ask @@team about (@@main.go) and a@@b\n```python\n@@decorator\n```"}
+
+	assert.Equal(t, "Ask @team about (@main.go) and a@@b\n```python\n@@decorator\n```", pair.Prompt())
+}
+
+func TestCommandLine(t *testing.T) {
+	for line, want := range map[string]string{
+		"!git status":   "git status",
+		"! ls -la ":     "ls -la",
+		"!":             "",
+		"!!not a thing": "",
+		"![alt](x.png)": "",
+		"git status":    "",
+		" !indented":    "",
+	} {
+		command, ok := commandLine(line)
+		assert.Equal(t, want, command, line)
+		assert.Equal(t, want != "", ok, line)
+	}
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"tama/internal/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Regenerating a response keeps the earlier ones as alternatives. As with
+// branches, the active response stays in the pair's own fields, so it is the
+// one sent as context for later requests.
+
+// selectResponse makes response selected of all the pair's active one
+func selectResponse(pair *MessagePair, all []session.Alternative, selected int) {
+	pair.SetActive(all[selected])
+	pair.Alternatives = slices.Delete(slices.Clone(all), selected, selected+1)
+	pair.Selected = selected
+	if len(pair.Alternatives) == 0 {
+		pair.Alternatives = nil
+	}
+}
+
+// regenerate resends the conversation up to the focused pair, adding the
+// answer as a new response to it
+func (m *Model) regenerate() tea.Cmd {
+	i := m.CurrentPairIndex
+	if i >= len(m.MessagePairs) {
+		return nil
+	}
+	pair := &m.MessagePairs[i]
+	all := pair.Responses()
+	if pair.Response == "" {
+		// Nothing worth keeping, e.g. a cancelled request
+		all = slices.Delete(all, pair.Selected, pair.Selected+1)
+	}
+	m.RegeneratedFrom = pair.Selected
+	all = append(all, session.Alternative{
+		Model:       m.CurrentModel,
+		RequestedAt: time.Now(),
+		Options:     m.options(),
+	})
+	selectResponse(pair, all, len(all)-1)
+
+	m.Regenerating = true
+	m.ResponseTargetIndex = i
+	return m.startRequest()
+}
+
+// abandonRegenerate drops an unfinished regenerated response, returning to
+// the one shown before
+func (m *Model) abandonRegenerate() {
+	m.Regenerating = false
+	if m.ResponseTargetIndex >= len(m.MessagePairs) {
+		return
+	}
+	pair := &m.MessagePairs[m.ResponseTargetIndex]
+	if len(pair.Alternatives) == 0 {
+		// No earlier response to return to; leave it cancelled
+		pair.Cancelled = true
+		return
+	}
+	all := slices.Delete(pair.Responses(), pair.Selected, pair.Selected+1)
+	selectResponse(pair, all, min(m.RegeneratedFrom, len(all)-1))
+}
+
+// switchResponse shows the focused pair's next (delta 1) or previous
+// (delta -1) response, wrapping around; it becomes the one used as context
+func (m *Model) switchResponse(delta int) {
+	if m.CurrentPairIndex >= len(m.MessagePairs) {
+		return
+	}
+	pair := &m.MessagePairs[m.CurrentPairIndex]
+	all := pair.Responses()
+	if len(all) < 2 {
+		return
+	}
+	selectResponse(pair, all, (pair.Selected+delta+len(all))%len(all))
+	m.saveSession()
+	m.updateViewport()
+	m.Viewport.GotoTop()
+}
+
+// responseStatus describes which of the focused pair's responses is shown,
+// e.g. "response 2/3", or "" if it was never regenerated
+func (m Model) responseStatus() string {
+	if m.CurrentPairIndex >= len(m.MessagePairs) {
+		return ""
+	}
+	pair := m.MessagePairs[m.CurrentPairIndex]
+	if len(pair.Alternatives) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("response %d/%d", pair.Selected+1, len(pair.Alternatives)+1)
+}
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegenerateKeepsEarlierResponses(t *testing.T) {
+	// Given a conversation focused on its first pair
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 0
+
+	// When the user presses "r"
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updatedModel.(Model)
+
+	// Then the conversation up to that pair is resent
+	require.NotNil(t, cmd)
+	assert.True(t, m.ChatRequested)
+	assert.Equal(t, 0, m.ResponseTargetIndex)
+	req := m.newChatRequest(m.MessagePairs[:m.ResponseTargetIndex+1])
+	require.Len(t, req.Messages, 1)
+	assert.Equal(t, "Hi", req.Messages[0].Content)
+
+	// When the new response arrives
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Hey there"})
+	m = updatedModel.(Model)
+
+	// Then it is shown, with the earlier one kept as an alternative
+	assert.Equal(t, "Hey there", m.MessagePairs[0].Response)
+	assert.Len(t, m.MessagePairs, 3, "Later pairs are kept")
+	assert.Contains(t, m.View(), "response 2/2")
+
+	// And it is the one used as context for later turns
+	req = m.newChatRequest(m.MessagePairs)
+	assert.Equal(t, "Hey there", req.Messages[1].Content)
+
+	// When the user flips back with "["
+	m = pressKey(t, m, '[')
+
+	// Then the earlier response is shown and used as context
+	assert.Equal(t, "Hello", m.MessagePairs[0].Response)
+	assert.Contains(t, m.View(), "response 1/2")
+	req = m.newChatRequest(m.MessagePairs)
+	assert.Equal(t, "Hello", req.Messages[1].Content)
+
+	// And the choice is saved
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", saved.Pairs[0].Response)
+	require.Len(t, saved.Pairs[0].Alternatives, 1)
+	assert.Equal(t, "Hey there", saved.Pairs[0].Alternatives[0].Response)
+}
+
+func TestCancelledRegenerateRestoresResponse(t *testing.T) {
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 1
+	m = pressKey(t, m, 'r')
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updatedModel.(Model)
+
+	assert.Equal(t, "Did you mean what?", m.MessagePairs[1].Response)
+	assert.False(t, m.MessagePairs[1].Cancelled)
+	assert.Empty(t, m.MessagePairs[1].Alternatives)
+	assert.Empty(t, m.responseStatus())
+}
+
+func TestLateResponseToCancelledRegenerateIsDropped(t *testing.T) {
+	// Given a regenerate that streamed part of a response
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 1
+	m = pressKey(t, m, 'r')
+	cancelled := m.RequestID
+	updatedModel, _ := m.Update(ResponseLineMsg{RequestID: cancelled, Text: "Go is"})
+	m = updatedModel.(Model)
+
+	// When the user cancels it and its stream then ends
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updatedModel.(Model)
+	for _, msg := range []tea.Msg{
+		ThinkingLineMsg{RequestID: cancelled, Thinking: "Hmm"},
+		ResponseMetricsMsg{RequestID: cancelled},
+		ResponseCompleteMsg{RequestID: cancelled, Response: "Go is"},
+		responseFailedMsg{requestID: cancelled, err: context.Canceled},
+	} {
+		updatedModel, _ = m.Update(msg)
+		m = updatedModel.(Model)
+	}
+
+	// Then the earlier response is kept, and saved
+	assert.Equal(t, "Did you mean what?", m.MessagePairs[1].Response)
+	assert.Empty(t, m.MessagePairs[1].Thinking)
+	assert.Empty(t, m.MessagePairs[1].Error)
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Did you mean what?", saved.Pairs[1].Response)
+
+	// And the response to a later request is still taken
+	m = pressKey(t, m, 'r')
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Go is a language"})
+	m = updatedModel.(Model)
+	assert.Equal(t, "Go is a language", m.MessagePairs[1].Response)
+}
+
+func TestStatusErrorDoesNotAbandonRegenerate(t *testing.T) {
+	// Given a regenerate in progress
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 1
+	m = pressKey(t, m, 'r')
+
+	// When a background status check fails
+	updatedModel, _ := m.Update(errorMsg{err: errors.New("connection refused")})
+	m = updatedModel.(Model)
+
+	// Then the regenerate goes on and its response is still taken
+	assert.True(t, m.Regenerating)
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Go is a language"})
+	m = updatedModel.(Model)
+	assert.Equal(t, "Go is a language", m.MessagePairs[1].Response)
+	assert.Equal(t, "response 2/2", m.responseStatus())
+}
+
+func TestRegenerateCancelledRequestRetries(t *testing.T) {
+	m := newSessionModel(t, threePairs...)
+	m.MessagePairs[2] = MessagePair{Request: "Yes", Cancelled: true}
+	m.CurrentPairIndex = 2
+
+	m = pressKey(t, m, 'r')
+	updatedModel, _ := m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "A language"})
+	m = updatedModel.(Model)
+
+	assert.Equal(t, "A language", m.MessagePairs[2].Response)
+	assert.False(t, m.MessagePairs[2].Cancelled)
+	assert.Empty(t, m.MessagePairs[2].Alternatives, "An empty cancelled response is not kept")
+}
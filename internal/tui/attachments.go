@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"tama/internal/session"
+)
+
+// maxAttachmentSize is the largest file that can be attached with @path
+const maxAttachmentSize = 256_000
+
+// attachmentLangs tags the fenced blocks of attached files by extension,
+// where the tag is not the extension itself
+var attachmentLangs = map[string]string{
+	".py":   "python",
+	".js":   "javascript",
+	".mjs":  "javascript",
+	".ts":   "typescript",
+	".tsx":  "tsx",
+	".rs":   "rust",
+	".rb":   "ruby",
+	".md":   "markdown",
+	".yml":  "yaml",
+	".h":    "c",
+	".hpp":  "cpp",
+	".cc":   "cpp",
+	".kt":   "kotlin",
+	".zsh":  "sh",
+	".bash": "sh",
+}
+
+// attachmentLang returns the language tag for the file at path
+func attachmentLang(path string) string {
+	base := filepath.Base(path)
+	switch base {
+	case "Makefile", "Dockerfile":
+		return strings.ToLower(base)
+	}
+	ext := strings.ToLower(filepath.Ext(base))
+	if lang, ok := attachmentLangs[ext]; ok {
+		return lang
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// attachmentRefs returns the @path references in text, in order and each
+// once: words starting with @, without trailing punctuation unless it is
+// part of an existing path. Words starting with @@ are escaped, and fenced
+// code blocks are left alone.
+func attachmentRefs(text string) []string {
+	var words []string
+	var f session.Fences
+	for _, line := range strings.Split(text, "\n") {
+		if f.Scan(line) != session.Text {
+			continue
+		}
+		words = append(words, strings.FieldsFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '(' || r == '`'
+		})...)
+	}
+	var refs []string
+	for _, word := range words {
+		if len(word) < 2 || word[0] != '@' || word[1] == '@' {
+			continue
+		}
+		ref := word[1:]
+		if _, err := os.Stat(expandHome(ref)); err != nil {
+			ref = strings.TrimRight(ref, ".,;:!?)`'\"")
+		}
+		if ref != "" && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// looksLikePath reports whether ref was meant as a file, having a directory
+// or an extension, rather than being a handle such as @channel
+func looksLikePath(ref string) bool {
+	return strings.ContainsRune(ref, '/') || filepath.Ext(ref) != ""
+}
+
+// attachFiles reads the files referenced in text with @path. References
+// that name no file are left as text, and those that look like paths are
+// returned so the user can be told. Directories, files over the size limit
+// and binary files are errors.
+func attachFiles(text string) ([]session.Attachment, []string, error) {
+	var attachments []session.Attachment
+	var missing []string
+	for _, ref := range attachmentRefs(text) {
+		path := expandHome(ref)
+		info, err := os.Stat(path)
+		if err != nil {
+			if looksLikePath(ref) {
+				missing = append(missing, "@"+ref)
+			}
+			continue
+		}
+		if info.IsDir() {
+			return nil, nil, fmt.Errorf("@%s is a directory; attach the files in it", ref)
+		}
+		if info.Size() > maxAttachmentSize {
+			return nil, nil, fmt.Errorf("@%s is %s; attachments are limited to %s", ref, formatSize(info.Size()), formatSize(maxAttachmentSize))
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("@%s: %w", ref, err)
+		}
+		if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+			return nil, nil, fmt.Errorf("@%s is not a text file", ref)
+		}
+		attachments = append(attachments, session.Attachment{
+			Path:    ref,
+			Lang:    attachmentLang(ref),
+			Content: string(data),
+		})
+	}
+	return attachments, missing, nil
+}
+
+// attachmentNames lists the files attached to pair, for the request border
+func attachmentNames(pair MessagePair) string {
+	names := make([]string, len(pair.Attachments))
+	for i, a := range pair.Attachments {
+		names[i] = a.Path
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkRequestSize refuses a request whose attachments and command output
+// would not fit in the context window, unless it is sent a second time as
+// it was. The output of a refused request's commands is kept, so sending
+// it again does not run them again.
+func (m *Model) checkRequestSize(input string, pair MessagePair) bool {
+	if len(pair.Attachments) == 0 && len(pair.Commands) == 0 {
+		return true
+	}
+	tokens := estimateTokens(pair.Prompt())
+	if tokens <= m.contextBudget() || m.OversizeConfirmed == input {
+		return true
+	}
+	m.OversizeConfirmed = input
+	m.OversizeCommands = pair.Commands
+	m.setNotice(fmt.Sprintf("with what it attaches this request is about %s tokens, more than fits in the %s-token context; send again to send anyway",
+		formatCount(tokens), formatCount(m.numCtx())), true)
+	return false
+}
+
+// forgetOversize drops the warning about an oversized request, so sending
+// it again checks it, and runs its commands, afresh
+func (m *Model) forgetOversize() {
+	m.OversizeConfirmed, m.OversizeCommands = "", nil
+}
+
+// completeAttachment completes the @path before the cursor on tab, listing
+// the candidates when there is more than one. It reports whether the cursor
+// was on an @path.
+func (m *Model) completeAttachment() bool {
+	line := []rune(strings.Split(m.Textarea.Value(), "\n")[m.Textarea.Line()])
+	info := m.Textarea.LineInfo()
+	col := min(info.StartColumn+info.ColumnOffset, len(line))
+	start := col
+	for start > 0 && !unicode.IsSpace(line[start-1]) {
+		start--
+	}
+	word := string(line[start:col])
+	if !strings.HasPrefix(word, "@") {
+		return false
+	}
+
+	dir, prefix := filepath.Split(word[1:])
+	entries, err := os.ReadDir(expandHome(cmp.Or(dir, ".")))
+	if err != nil {
+		return true
+	}
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		candidates = append(candidates, name)
+	}
+
+	switch len(candidates) {
+	case 0:
+	case 1:
+		completion := candidates[0][len(prefix):]
+		if !strings.HasSuffix(completion, "/") && (col == len(line) || !unicode.IsSpace(line[col])) {
+			completion += " "
+		}
+		m.Textarea.InsertString(completion)
+	default:
+		if common := commonPrefix(candidates); len(common) > len(prefix) {
+			m.Textarea.InsertString(common[len(prefix):])
+		}
+		m.setNotice(strings.Join(candidates, "  "), false)
+	}
+	m.fitPrompt()
+	return true
+}
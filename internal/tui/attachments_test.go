@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tama/internal/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inProject runs the test in a directory holding files, given by path
+func inProject(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+	t.Chdir(dir)
+}
+
+func TestAttachFiles(t *testing.T) {
+	inProject(t, map[string]string{
+		"main.go":           "package main\n",
+		"docs/notes.txt":    "remember\n",
+		"scripts/build.zsh": "make\n",
+	})
+
+	attachments, missing, err := attachFiles("Review @main.go, and @docs/notes.txt. Also (@scripts/build.zsh) and @main.go again. cc @team")
+
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	assert.Equal(t, []session.Attachment{
+		{Path: "main.go", Lang: "go", Content: "package main\n"},
+		{Path: "docs/notes.txt", Lang: "txt", Content: "remember\n"},
+		{Path: "scripts/build.zsh", Lang: "sh", Content: "make\n"},
+	}, attachments)
+}
+
+func TestAttachFilesErrors(t *testing.T) {
+	inProject(t, map[string]string{
+		"docs/notes.txt": "remember",
+		"big.log":        strings.Repeat("x", maxAttachmentSize+1),
+		"image.png":      "\x89PNG\x00\x00",
+	})
+
+	for input, message := range map[string]string{
+		"see @docs":      "@docs is a directory",
+		"see @big.log":   "@big.log is 256.0 KB; attachments are limited to 256.0 KB",
+		"see @image.png": "@image.png is not a text file",
+	} {
+		_, _, err := attachFiles(input)
+		assert.ErrorContains(t, err, message, input)
+	}
+}
+
+func TestAttachFilesSkipsMissingAndEscapedPaths(t *testing.T) {
+	inProject(t, map[string]string{"main.go": "package main\n"})
+
+	attachments, missing, err := attachFiles("install @types/node for @app.route, not @@main.go, cc @team")
+
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+	assert.Equal(t, []string{"@types/node", "@app.route"}, missing)
+}
+
+func TestAttachFilesSkipsFencedCode(t *testing.T) {
+	inProject(t, map[string]string{"main.go": "package main\n", "app.py": "print(1)\n"})
+
+	attachments, missing, err := attachFiles("Why does @main.go fail?\n```python\n@app.py\n@missing.route(\"/\")\n```")
+
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "main.go", attachments[0].Path)
+	assert.Empty(t, missing)
+}
+
+func TestSendRequestWithAttachment(t *testing.T) {
+	// Given a project with a file
+	inProject(t, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	m := newPromptModeModel(t)
+
+	// When the user sends a request referencing it
+	m, cmd := submit(t, m, "What does @main.go do?")
+
+	// Then the request is kept as typed, with the file attached
+	require.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	pair := m.MessagePairs[0]
+	assert.Equal(t, "What does @main.go do?", pair.Request)
+	require.Len(t, pair.Attachments, 1)
+	assert.Equal(t, "main.go", pair.Attachments[0].Path)
+
+	// And the model is sent the file's contents in a fenced block
+	messages := m.newChatRequest(m.MessagePairs).Messages
+	assert.Equal(t, "What does @main.go do?\n\nFile: main.go\n```go\npackage main\n\nfunc main() {}\n```", messages[len(messages)-1].Content)
+
+	// And the viewport names the file above the request instead of showing it
+	m.updateViewport()
+	view := m.Viewport.View()
+	assert.Contains(t, view, "Request (attached main.go)")
+	assert.NotContains(t, view, "func main()")
+}
+
+func TestAttachmentErrorKeepsInput(t *testing.T) {
+	// Given a prompt referencing a directory
+	inProject(t, map[string]string{"docs/notes.txt": "remember"})
+	m := newPromptModeModel(t)
+
+	// When the user sends it
+	m, cmd := submit(t, m, "Fix @docs")
+
+	// Then nothing is sent and the error is shown
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.MessagePairs)
+	assert.Equal(t, "Fix @docs", m.Textarea.Value())
+	assert.True(t, m.NoticeIsError)
+	assert.Contains(t, m.Notice, "@docs is a directory")
+}
+
+func TestMissingAttachmentIsSentAsText(t *testing.T) {
+	// Given a prompt with an @word that looks like a path but is no file
+	inProject(t, nil)
+	m := newPromptModeModel(t)
+
+	// When the user sends it
+	m, cmd := submit(t, m, "How do I install @types/node?")
+
+	// Then it is sent as typed, without attachments
+	require.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "How do I install @types/node?", m.MessagePairs[0].Request)
+	assert.Empty(t, m.MessagePairs[0].Attachments)
+
+	// And the user is told it was not attached
+	assert.False(t, m.NoticeIsError)
+	assert.Equal(t, "@types/node: no such file, sent as text", m.Notice)
+}
+
+func TestEscapedAttachmentIsSentAsText(t *testing.T) {
+	// Given a project with a file
+	inProject(t, map[string]string{"main.go": "package main\n"})
+	m := newPromptModeModel(t)
+
+	// When the user sends a request escaping its name with @@
+	m, cmd := submit(t, m, "Who wrote @@main.go?")
+
+	// Then the request is kept as typed, with nothing attached
+	require.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "Who wrote @@main.go?", m.MessagePairs[0].Request)
+	assert.Empty(t, m.MessagePairs[0].Attachments)
+
+	// And the model is sent a single @
+	assert.Equal(t, "Who wrote @main.go?", m.MessagePairs[0].Prompt())
+}
+
+func TestOversizedAttachmentWarns(t *testing.T) {
+	// Given a file bigger than the model's context window
+	inProject(t, map[string]string{"dump.txt": strings.Repeat("lorem ipsum ", 2000)})
+	m := newPromptModeModel(t)
+	m.ContextLength = 2048
+
+	// When the user sends a request attaching it
+	m, cmd := submit(t, m, "Summarize @dump.txt")
+
+	// Then they are warned instead
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.MessagePairs)
+	assert.Contains(t, m.Notice, "about 6.0k tokens, more than fits in the 2.0k-token context")
+	assert.Equal(t, "Summarize @dump.txt", m.Textarea.Value())
+
+	// When they send it again
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then it is sent anyway
+	assert.NotNil(t, cmd)
+	assert.Len(t, m.MessagePairs, 1)
+}
+
+func TestTabCompletesAttachmentPaths(t *testing.T) {
+	// Given a project
+	inProject(t, map[string]string{
+		"internal/tui/model.go": "",
+		"main.go":               "",
+		"main_test.go":          "",
+		".env":                  "",
+	})
+	m := newPromptModeModel(t)
+
+	// When the user types an @path of a directory and presses tab
+	m = typeText(t, m, "Read @int")
+	m, _ = pressType(t, m, tea.KeyTab)
+
+	// Then the directory is completed, ready for the file in it
+	assert.Equal(t, "Read @internal/", m.Textarea.Value())
+	m = typeText(t, m, "tui/m")
+	m, _ = pressType(t, m, tea.KeyTab)
+	assert.Equal(t, "Read @internal/tui/model.go ", m.Textarea.Value())
+
+	// When more than one file matches
+	m = typeText(t, m, "and @m")
+	m, _ = pressType(t, m, tea.KeyTab)
+
+	// Then their common prefix is completed and the candidates listed
+	assert.Equal(t, "Read @internal/tui/model.go and @main", m.Textarea.Value())
+	assert.Equal(t, "main.go  main_test.go", m.Notice)
+}
+
+func TestTabCompletesAttachmentBeforeCursor(t *testing.T) {
+	// Given a prompt with text after an unfinished @path
+	inProject(t, map[string]string{"main.go": ""})
+	m := newPromptModeModel(t)
+	m.setPrompt("Read @ma please")
+	for range len(" please") {
+		m, _ = pressType(t, m, tea.KeyLeft)
+	}
+
+	// When the user presses tab
+	m, _ = pressType(t, m, tea.KeyTab)
+
+	// Then the path under the cursor is completed
+	assert.Equal(t, "Read @main.go please", m.Textarea.Value())
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The conversation is a tree: editing a request starts a new branch from
+// that point. MessagePairs always holds the active path, and the first pair
+// of a branch point keeps the inactive branches in Siblings, so everything
+// that reads the conversation sees a flat list.
+
+// branches returns every branch diverging at pair i in order, each the path
+// from i to its end. The active branch is the tail of MessagePairs.
+func (m Model) branches(i int) [][]MessagePair {
+	pair := m.MessagePairs[i]
+	active := slices.Clone(m.MessagePairs[i:])
+	active[0].Siblings = nil
+	active[0].Branch = 0
+	all := slices.Clone(pair.Siblings)
+	return slices.Insert(all, min(pair.Branch, len(all)), active)
+}
+
+// setBranches makes branch active of all the path from pair i onwards
+func (m *Model) setBranches(i int, all [][]MessagePair, active int) {
+	tail := slices.Clone(all[active])
+	tail[0].Siblings = slices.Delete(slices.Clone(all), active, active+1)
+	tail[0].Branch = active
+	if len(tail[0].Siblings) == 0 {
+		tail[0].Siblings = nil
+	}
+	m.MessagePairs = append(m.MessagePairs[:i:i], tail...)
+}
+
+// forgetSummary drops the conversation summary before the active branch is
+// left at pair i, if the summary covers pair i or later: those pairs are not
+// on the other branch. The summarized pairs are sent in full again until
+// the next /compact.
+func (m *Model) forgetSummary(i int) {
+	last := -1
+	for j, pair := range m.MessagePairs {
+		if pair.Summarized {
+			last = j
+		}
+	}
+	if last < i {
+		return
+	}
+	for j := range m.MessagePairs[:last+1] {
+		m.MessagePairs[j].Summarized = false
+	}
+	if m.Session != nil {
+		m.Session.Summary = ""
+	}
+	m.setNotice("summary dropped: it covers messages not on this branch", false)
+}
+
+// branchFrom starts a new branch at pair i with pair as its first request
+func (m *Model) branchFrom(i int, pair MessagePair) {
+	m.forgetSummary(i)
+	all := append(m.branches(i), []MessagePair{pair})
+	m.setBranches(i, all, len(all)-1)
+}
+
+// switchBranch moves the focused pair to its next (delta 1) or previous
+// (delta -1) sibling branch, wrapping around
+func (m *Model) switchBranch(delta int) {
+	if m.CurrentPairIndex >= len(m.MessagePairs) {
+		return
+	}
+	if len(m.MessagePairs[m.CurrentPairIndex].Siblings) == 0 {
+		return
+	}
+	m.forgetSummary(m.CurrentPairIndex)
+	all := m.branches(m.CurrentPairIndex)
+	active := (m.MessagePairs[m.CurrentPairIndex].Branch + delta + len(all)) % len(all)
+	m.setBranches(m.CurrentPairIndex, all, active)
+	m.saveSession()
+	m.updateViewport()
+	m.Viewport.GotoTop()
+}
+
+// startEdit loads the focused request into the prompt; sending it creates
+// a new branch from that point
+func (m *Model) startEdit() {
+	if m.CurrentPairIndex >= len(m.MessagePairs) {
+		return
+	}
+	m.Editing = true
+	m.EditIndex = m.CurrentPairIndex
+	m.Mode = PromptMode
+	m.Textarea.Focus()
+	request := m.MessagePairs[m.CurrentPairIndex].Request
+	if strings.HasPrefix(request, "/") {
+		// Typed as "//", which was stored as a single slash
+		request = "/" + request
+	}
+	m.setPrompt(request)
+}
+
+// branchStatus describes the focused pair's place among its siblings, e.g.
+// "branch 2/3", or "" if the conversation never branched there
+func (m Model) branchStatus() string {
+	if m.CurrentPairIndex >= len(m.MessagePairs) {
+		return ""
+	}
+	pair := m.MessagePairs[m.CurrentPairIndex]
+	if len(pair.Siblings) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("branch %d/%d", pair.Branch+1, len(pair.Siblings)+1)
+}
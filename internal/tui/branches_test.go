@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pressKey(t *testing.T, m Model, key rune) Model {
+	t.Helper()
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+	return updatedModel.(Model)
+}
+
+// threePairs is a conversation of three completed pairs, with a typo in
+// the second request
+var threePairs = []MessagePair{
+	{Request: "Hi", Response: "Hello"},
+	{Request: "Waht is Go?", Response: "Did you mean what?"},
+	{Request: "Yes", Response: "A language"},
+}
+
+func TestEditRequestStartsBranch(t *testing.T) {
+	// Given a conversation with a typo in the second request
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 1
+
+	// When the user presses "c" on it
+	m = pressKey(t, m, 'c')
+
+	// Then the request is loaded into the prompt
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "Waht is Go?", m.Textarea.Value())
+	assert.Contains(t, m.View(), "Editing MSG 2")
+
+	// When the fixed request is sent
+	m, cmd := submit(t, m, "What is Go?")
+	require.NotNil(t, cmd)
+
+	// Then it replaces the rest of the conversation as a new branch
+	require.Len(t, m.MessagePairs, 2)
+	assert.Equal(t, "What is Go?", m.MessagePairs[1].Request)
+	assert.Equal(t, 1, m.CurrentPairIndex)
+	assert.Equal(t, 1, m.ResponseTargetIndex)
+	assert.Contains(t, m.View(), "MSG 2/2 • branch 2/2")
+
+	// And only the new branch is sent as context
+	req := m.newChatRequest(m.MessagePairs)
+	require.Len(t, req.Messages, 3)
+	assert.Equal(t, "What is Go?", req.Messages[2].Content)
+
+	// When the response arrives and the user cycles back
+	updatedModel, _ := m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "A language from Google"})
+	m = updatedModel.(Model)
+	m = pressKey(t, m, '<')
+
+	// Then the original branch is restored in full
+	require.Len(t, m.MessagePairs, 3)
+	assert.Equal(t, "Waht is Go?", m.MessagePairs[1].Request)
+	assert.Equal(t, "Yes", m.MessagePairs[2].Request)
+	assert.Contains(t, m.View(), "branch 1/2")
+
+	// And cycling forward wraps to the new branch with its response
+	m = pressKey(t, m, '>')
+	require.Len(t, m.MessagePairs, 2)
+	assert.Equal(t, "A language from Google", m.MessagePairs[1].Response)
+}
+
+func TestEditRequestKeepsEscapes(t *testing.T) {
+	// Given a sent request with an escaped slash and an escaped @path
+	inProject(t, map[string]string{"main.go": "package main\n"})
+	m := pressKey(t, newSessionModel(t), 'i')
+	m, _ = submit(t, m, "//help with @@main.go")
+	updatedModel, _ := m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Sure"})
+	m = updatedModel.(Model)
+
+	// When the user edits it
+	m = pressKey(t, m, 'c')
+
+	// Then the prompt holds it as it was typed
+	assert.Equal(t, "//help with @@main.go", m.Textarea.Value())
+
+	// And sending it again sends the same request, not a command or a file
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+	require.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "/help with @@main.go", m.MessagePairs[0].Request)
+	assert.Empty(t, m.MessagePairs[0].Attachments)
+	assert.Contains(t, m.View(), "branch 2/2")
+}
+
+func TestBranchesAreSavedWithSession(t *testing.T) {
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 1
+	m = pressKey(t, m, 'c')
+	m, _ = submit(t, m, "What is Go?")
+	updatedModel, _ := m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "A language"})
+	m = updatedModel.(Model)
+
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	m.LoadSession(saved)
+	m.CurrentPairIndex = 1
+
+	assert.Len(t, m.MessagePairs, 2)
+	m.switchBranch(1)
+	assert.Len(t, m.MessagePairs, 3, "The old branch survives a save and load")
+}
+
+func TestBranchingAtSummarizedPairDropsSummary(t *testing.T) {
+	// Given a conversation whose first two pairs were compacted
+	m := newSessionModel(t, threePairs...)
+	m.MessagePairs[0].Summarized = true
+	m.MessagePairs[1].Summarized = true
+	m.Session.Summary = "The user asked what Go is."
+
+	// When the user edits the second request
+	m.CurrentPairIndex = 1
+	m = pressKey(t, m, 'c')
+	m, _ = submit(t, m, "What is Rust?")
+
+	// Then the summary, which covers the old branch, is not sent with it
+	assert.Empty(t, m.Session.Summary)
+	assert.Contains(t, m.Notice, "summary dropped")
+	req := m.newChatRequest(m.MessagePairs)
+	require.Len(t, req.Messages, 3)
+	assert.Equal(t, "Hi", req.Messages[0].Content, "The first pair is sent in full again")
+
+	// And the old branch is no longer marked summarized either
+	m.CurrentPairIndex = 1
+	m.switchBranch(-1)
+	for _, pair := range m.MessagePairs {
+		assert.False(t, pair.Summarized)
+	}
+}
+
+func TestBranchingAfterSummaryKeepsIt(t *testing.T) {
+	m := newSessionModel(t, threePairs...)
+	m.MessagePairs[0].Summarized = true
+	m.Session.Summary = "The user said hi."
+
+	m.CurrentPairIndex = 1
+	m = pressKey(t, m, 'c')
+	m, _ = submit(t, m, "What is Rust?")
+
+	assert.Equal(t, "The user said hi.", m.Session.Summary)
+	assert.True(t, m.MessagePairs[0].Summarized)
+}
+
+func TestEscAbandonsEdit(t *testing.T) {
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 0
+	m = pressKey(t, m, 'c')
+
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updatedModel.(Model)
+
+	assert.False(t, m.Editing)
+	assert.Empty(t, m.Textarea.Value())
+	m = pressKey(t, m, 'i')
+	m, _ = submit(t, m, "Another question")
+	assert.Len(t, m.MessagePairs, 4, "A normal send appends to the conversation")
+	assert.Empty(t, m.branchStatus())
+}
+
+func TestBranchKeysWithoutBranches(t *testing.T) {
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 2
+
+	m = pressKey(t, m, '>')
+
+	assert.Len(t, m.MessagePairs, 3)
+	assert.NotContains(t, m.View(), "branch")
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copiedMsg reports the outcome of copying what to the clipboard
+type copiedMsg struct {
+	what string
+	err  error
+}
+
+// writeClipboard copies text to the clipboard; tests replace it
+var writeClipboard = copyText
+
+// writeSystemClipboard sets the system clipboard; tests replace it
+var writeSystemClipboard = clipboard.WriteAll
+
+// TerminalOutput is the program's output, which clipboard escape sequences
+// are written to as well. Each write holds a lock, so a sequence written
+// from a command never lands in the middle of a frame.
+type TerminalOutput struct {
+	*os.File
+	mu sync.Mutex
+}
+
+// Output is passed to the program with tea.WithOutput
+var Output = &TerminalOutput{File: os.Stdout}
+
+func (o *TerminalOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+func (o *TerminalOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// copyText copies text with an OSC 52 escape sequence, which the terminal
+// handles even over SSH, falling back to the system clipboard when the
+// output is not a terminal
+func copyText(text string) error {
+	if !isTerminal(Output.File) {
+		return writeSystemClipboard(text)
+	}
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(Output)
+	return err
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func copyCmd(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		return copiedMsg{what: what, err: writeClipboard(text)}
+	}
+}
+
+// yank copies part of the focused pair chosen by the key pressed after 'y':
+// 'y' the response, 'r' the request, or a digit the response's code block
+// with that number, all as raw markdown
+func (m *Model) yank(key rune) tea.Cmd {
+	if m.CurrentPairIndex >= len(m.MessagePairs) {
+		return nil
+	}
+	pair := m.MessagePairs[m.CurrentPairIndex]
+	switch {
+	case key == 'y':
+		if pair.Response == "" {
+			m.setNotice("no response to copy", true)
+			return nil
+		}
+		return copyCmd(pair.Response, "response")
+	case key == 'r':
+		return copyCmd(pair.Request, "request")
+	case key >= '1' && key <= '9':
+		n := int(key - '0')
+		blocks := codeBlocks(pair.Response)
+		if n > len(blocks) {
+			m.setNotice(fmt.Sprintf("no code block %d (the response has %d)", n, len(blocks)), true)
+			return nil
+		}
+		return copyCmd(blocks[n-1].Code, fmt.Sprintf("code block %d", n))
+	}
+	return nil
+}
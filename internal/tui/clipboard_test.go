@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClipboard records what is copied instead of touching the clipboard
+func fakeClipboard(t *testing.T, err error) *string {
+	t.Helper()
+	var copied string
+	original := writeClipboard
+	writeClipboard = func(text string) error {
+		copied = text
+		return err
+	}
+	t.Cleanup(func() { writeClipboard = original })
+	return &copied
+}
+
+// yankKeys presses 'y' then key, running the copy it starts
+func yankKeys(t *testing.T, m Model, key rune) Model {
+	t.Helper()
+	m = pressKey(t, m, 'y')
+	assert.Contains(t, m.View(), "copy: y response")
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+	m = updatedModel.(Model)
+	if cmd != nil {
+		updatedModel, _ = m.Update(cmd())
+		m = updatedModel.(Model)
+	}
+	return m
+}
+
+// yankPair has a response with two code blocks
+var yankPair = MessagePair{
+	Request:  "How do I list files?",
+	Response: "Use **ls**:\n\n```sh\nls -la\n```\n\nOr in Go:\n\n```go\nos.ReadDir(\".\")\n```",
+}
+
+func TestYankCopiesRawMarkdown(t *testing.T) {
+	tests := []struct {
+		key    rune
+		copied string
+		notice string
+	}{
+		{'y', "Use **ls**:\n\n```sh\nls -la\n```\n\nOr in Go:\n\n```go\nos.ReadDir(\".\")\n```", "copied response"},
+		{'r', "How do I list files?", "copied request"},
+		{'1', "ls -la", "copied code block 1"},
+		{'2', `os.ReadDir(".")`, "copied code block 2"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.key), func(t *testing.T) {
+			copied := fakeClipboard(t, nil)
+			m := newSessionModel(t, yankPair)
+
+			m = yankKeys(t, m, tt.key)
+
+			assert.Equal(t, tt.copied, *copied)
+			assert.Contains(t, m.View(), tt.notice)
+			assert.False(t, m.NoticeIsError)
+		})
+	}
+}
+
+func TestYankMissingCodeBlock(t *testing.T) {
+	copied := fakeClipboard(t, nil)
+	m := newSessionModel(t, yankPair)
+
+	m = yankKeys(t, m, '3')
+
+	assert.Empty(t, *copied)
+	assert.True(t, m.NoticeIsError)
+	assert.Contains(t, m.View(), "no code block 3 (the response has 2)")
+}
+
+func TestYankReportsClipboardErrors(t *testing.T) {
+	fakeClipboard(t, errors.New("no clipboard utility"))
+	m := newSessionModel(t, yankPair)
+
+	m = yankKeys(t, m, 'y')
+
+	assert.True(t, m.NoticeIsError)
+	assert.Contains(t, m.View(), "copy failed: no clipboard utility")
+}
+
+func TestYankIsCancelledByOtherKeys(t *testing.T) {
+	// Given 'y' was pressed
+	m := newSessionModel(t, yankPair)
+	m = pressKey(t, m, 'y')
+	require.True(t, m.PendingYank)
+
+	// When another key is pressed, then '1'
+	m = pressKey(t, m, 'x')
+	assert.False(t, m.PendingYank)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+
+	// Then nothing is copied
+	assert.Nil(t, cmd)
+}
+
+func TestTerminalOutputKeepsWritesWhole(t *testing.T) {
+	// Given the program's output on a pipe, still usable as a terminal
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	out := &TerminalOutput{File: w}
+	var _ term.File = out
+
+	// When a frame and a clipboard sequence, each longer than the pipe
+	// writes atomically, are written at once
+	frame := strings.Repeat("f", 1<<16)
+	seq := strings.Repeat("c", 1<<16)
+	var wg sync.WaitGroup
+	for _, s := range []string{frame, seq} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := out.WriteString(s)
+			assert.NoError(t, err)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		w.Close()
+	}()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	// Then neither is split by the other
+	assert.True(t, string(data) == frame+seq || string(data) == seq+frame)
+}
+
+// useOutput makes the program's output f for the test, with the system
+// clipboard recording what it is given
+func useOutput(t *testing.T, f *os.File, err error) *string {
+	t.Helper()
+	var copied string
+	output, system := Output, writeSystemClipboard
+	Output = &TerminalOutput{File: f}
+	writeSystemClipboard = func(text string) error {
+		copied = text
+		return err
+	}
+	t.Cleanup(func() { Output, writeSystemClipboard = output, system })
+	return &copied
+}
+
+func TestCopyTextWritesOSC52ToTerminal(t *testing.T) {
+	// Given output to a character device, as a terminal is
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer tty.Close()
+	copied := useOutput(t, tty, errors.New("no clipboard utility"))
+
+	// When text is copied
+	err = copyText("ls -la")
+
+	// Then the escape sequence is written and the system clipboard is left
+	// alone
+	assert.NoError(t, err)
+	assert.Empty(t, *copied)
+}
+
+func TestCopyTextFallsBackToSystemClipboard(t *testing.T) {
+	// Given output that is not a terminal
+	_, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+	copied := useOutput(t, w, errors.New("no clipboard utility"))
+
+	// When text is copied
+	err = copyText("ls -la")
+
+	// Then the system clipboard is used, and its error returned
+	assert.Equal(t, "ls -la", *copied)
+	assert.EqualError(t, err, "no clipboard utility")
+}
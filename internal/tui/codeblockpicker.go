@@ -0,0 +1,314 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// runTimeout stops code blocks that run for too long
+const runTimeout = time.Minute
+
+// blockStage is what the code block overlay is doing
+type blockStage int
+
+const (
+	blockListing    blockStage = iota
+	blockPath                  // Typing the path to write the block to
+	blockConfirm               // Asking whether to overwrite an existing file
+	blockRunConfirm            // Asking whether to run the block with its runner
+	blockRunning               // Waiting for the block's runner to finish
+	blockOutput                // Showing what the runner printed
+)
+
+// listedBlock is a code block and the message it came from
+type listedBlock struct {
+	codeBlock
+	Source string // "response" or "request"
+}
+
+// blockPicker lists the code blocks of the focused pair in place of the
+// viewport, and copies, writes or runs the chosen one
+type blockPicker struct {
+	Blocks []listedBlock
+	Stage  blockStage
+	Path   textinput.Model // Where to write the block in blockPath
+	Target string          // Path being confirmed in blockConfirm
+	Runner string          // Command running the block, once confirmed
+	Output string          // What the runner printed
+	RunErr error           // Why the runner failed, if it did
+	cursor int
+	cancel func() // Stops the runner
+}
+
+// blockRunMsg carries the output of a code block's runner
+type blockRunMsg struct {
+	output string
+	err    error
+}
+
+// pairBlocks lists the code blocks of pair, the response's first so they
+// are numbered as for copying with y
+func pairBlocks(pair MessagePair) []listedBlock {
+	var blocks []listedBlock
+	for _, block := range codeBlocks(pair.Response) {
+		blocks = append(blocks, listedBlock{block, "response"})
+	}
+	for _, block := range codeBlocks(pair.Request) {
+		blocks = append(blocks, listedBlock{block, "request"})
+	}
+	return blocks
+}
+
+// openCodeBlocks shows the focused pair's code blocks
+func (m *Model) openCodeBlocks() {
+	if m.CurrentPairIndex >= len(m.MessagePairs) {
+		return
+	}
+	blocks := pairBlocks(m.MessagePairs[m.CurrentPairIndex])
+	if len(blocks) == 0 {
+		m.setNotice("no code blocks in this message", true)
+		return
+	}
+	m.Blocks = blockPicker{Blocks: blocks}
+	m.PreviousMode = m.Mode
+	m.Mode = CodeBlockMode
+}
+
+func (m *Model) closeCodeBlocks() {
+	if m.Blocks.cancel != nil {
+		m.Blocks.cancel()
+	}
+	m.Blocks = blockPicker{}
+	m.Mode = m.PreviousMode
+}
+
+// selectedBlock returns the block under the cursor and its number
+func (p blockPicker) selectedBlock() (listedBlock, int) {
+	return p.Blocks[p.cursor], p.cursor + 1
+}
+
+func (m Model) updateCodeBlocks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := &m.Blocks
+	block, n := p.selectedBlock()
+	switch p.Stage {
+	case blockListing:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			m.closeCodeBlocks()
+		case "up", "k", "ctrl+p":
+			p.cursor = max(p.cursor-1, 0)
+		case "down", "j", "ctrl+n":
+			p.cursor = min(p.cursor+1, len(p.Blocks)-1)
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if i := int(msg.Runes[0] - '1'); i < len(p.Blocks) {
+				p.cursor = i
+			}
+		case "enter", "y":
+			return m, copyCmd(block.Code, fmt.Sprintf("code block %d", n))
+		case "w":
+			ti := textinput.New()
+			ti.Prompt = "Write to: "
+			ti.Cursor.SetMode(cursor.CursorStatic)
+			ti.Focus()
+			p.Path = ti
+			p.Stage = blockPath
+		case "x":
+			runner := m.Config.RunnerFor(block.Lang)
+			if runner == "" {
+				m.setNotice(fmt.Sprintf("no runner for %q code; add one under runners in the config file", block.Lang), true)
+				return m, nil
+			}
+			// The code comes from the model; it runs only once confirmed
+			p.Runner = runner
+			p.Stage = blockRunConfirm
+		}
+
+	case blockPath:
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			p.Stage = blockListing
+		case tea.KeyEnter:
+			path := expandHome(strings.TrimSpace(p.Path.Value()))
+			if path == "" {
+				return m, nil
+			}
+			if _, err := os.Stat(path); err == nil {
+				p.Target = path
+				p.Stage = blockConfirm
+				return m, nil
+			}
+			m.writeBlock(block, path)
+		default:
+			var cmd tea.Cmd
+			p.Path, cmd = p.Path.Update(msg)
+			return m, cmd
+		}
+
+	case blockConfirm:
+		if msg.String() == "y" {
+			m.writeBlock(block, p.Target)
+		} else {
+			p.Stage = blockPath
+		}
+
+	case blockRunConfirm:
+		if msg.String() != "y" {
+			p.Stage = blockListing
+			return m, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+		p.cancel = cancel
+		p.Stage = blockRunning
+		return m, runBlockCmd(ctx, cancel, p.Runner, block.Code)
+
+	case blockRunning:
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC {
+			// The runner's result is reported as cancelled
+			p.cancel()
+		}
+
+	case blockOutput:
+		switch msg.String() {
+		case "enter":
+			// Offer the output as the next request
+			if m.IsWaiting || m.ChatRequested {
+				m.setNotice("wait for the response before sending the output", true)
+				return m, nil
+			}
+			request := runOutputRequest(block, n, p.Output, p.RunErr)
+			m.closeCodeBlocks()
+			m.Mode = PromptMode
+			m.Textarea.Focus()
+			m.setPrompt(request)
+		case "esc", "q", "ctrl+c":
+			p.Stage = blockListing
+		}
+	}
+	return m, nil
+}
+
+// writeBlock saves block to path, returning to the list
+func (m *Model) writeBlock(block listedBlock, path string) {
+	m.Blocks.Stage = blockListing
+	if err := os.WriteFile(path, []byte(block.Code+"\n"), 0644); err != nil {
+		m.setNotice(err.Error(), true)
+		return
+	}
+	m.setNotice("wrote "+path, false)
+}
+
+// runBlockCmd pipes code to runner, run by the shell
+func runBlockCmd(ctx context.Context, cancel func(), runner, code string) tea.Cmd {
+	return func() tea.Msg {
+		defer cancel()
+		cmd := shellCmd(ctx, runner)
+		cmd.Stdin = strings.NewReader(code + "\n")
+		output, err := cmd.CombinedOutput()
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			err = fmt.Errorf("stopped after %s", runTimeout)
+		case errors.Is(ctx.Err(), context.Canceled):
+			err = errors.New("stopped")
+		}
+		return blockRunMsg{output: string(output), err: err}
+	}
+}
+
+// showRunOutput shows what a code block printed, if it is still wanted
+func (m *Model) showRunOutput(msg blockRunMsg) {
+	if m.Mode != CodeBlockMode || m.Blocks.Stage != blockRunning {
+		return
+	}
+	m.Blocks.Stage = blockOutput
+	m.Blocks.Output = msg.output
+	m.Blocks.RunErr = msg.err
+	m.Blocks.cancel = nil
+}
+
+// runOutputRequest describes running block n, for sending to the model
+func runOutputRequest(block listedBlock, n int, output string, err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "I ran code block %d", n)
+	if block.Lang != "" {
+		fmt.Fprintf(&b, " (%s)", block.Lang)
+	}
+	if err != nil {
+		fmt.Fprintf(&b, ", which failed (%v)", err)
+	}
+	fmt.Fprintf(&b, ". Output:\n\n```\n%s\n```", strings.TrimRight(output, "\n"))
+	return b.String()
+}
+
+func (p blockPicker) View(width, height int) string {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+
+	var b strings.Builder
+	titleText := "──── Code blocks "
+	b.WriteString(lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Render(titleText + strings.Repeat("─", max(width-lipgloss.Width(titleText), 0))))
+	b.WriteString("\n")
+
+	for i, block := range p.Blocks {
+		lang := block.Lang
+		if lang == "" {
+			lang = "text"
+		}
+		lines := strings.Count(block.Code, "\n") + 1
+		label := fmt.Sprintf("%d. %s", i+1, lang)
+		line := "  " + label
+		if i == p.cursor {
+			line = selectedStyle.Render("› " + label)
+		}
+		b.WriteString(line + "  " + dimStyle.Render(fmt.Sprintf("%s • %d lines", block.Source, lines)) + "\n")
+	}
+	b.WriteString("\n")
+
+	block, _ := p.selectedBlock()
+	var body, footer string
+	switch p.Stage {
+	case blockListing:
+		body = block.Code
+		footer = "enter/y copy • w write to file • x run • esc close"
+	case blockPath:
+		body = block.Code
+		footer = p.Path.View()
+	case blockConfirm:
+		body = block.Code
+		footer = fmt.Sprintf("%s exists. Overwrite? y/n", p.Target)
+	case blockRunConfirm:
+		body = block.Code
+		footer = fmt.Sprintf("Run this block with %s? y/n", p.Runner)
+	case blockRunning:
+		body = block.Code
+		footer = fmt.Sprintf("Running %s… esc to stop", p.Runner)
+	case blockOutput:
+		body = p.Output
+		if p.RunErr != nil {
+			body += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(p.RunErr.Error())
+		}
+		footer = "enter put the output in the prompt • esc back"
+	}
+
+	// The selected block, or the output, fills the space left
+	bodyHeight := max(height-len(p.Blocks)-4, 1)
+	bodyLines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	if len(bodyLines) > bodyHeight {
+		bodyLines = append(bodyLines[:bodyHeight-1], "…")
+	}
+	b.WriteString(dimStyle.Width(width).Render(strings.Join(bodyLines, "\n")))
+	b.WriteString("\n\n")
+	b.WriteString(footer)
+
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(b.String())
+}
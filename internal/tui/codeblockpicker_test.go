@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blocksPair has a response with a shell and a Go block
+var blocksPair = MessagePair{
+	Request:  "Show me",
+	Response: "Try:\n\n```sh\necho hello\n```\n\nOr:\n\n```go\nfmt.Println(\"hello\")\n```",
+}
+
+func pressType(t *testing.T, m Model, key tea.KeyType) (Model, tea.Cmd) {
+	t.Helper()
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: key})
+	return updatedModel.(Model), cmd
+}
+
+func TestCodeBlockPickerListsBlocks(t *testing.T) {
+	m := pressKey(t, newSessionModel(t, blocksPair), 'b')
+
+	view := m.View()
+	assert.Contains(t, view, "Code blocks")
+	assert.Contains(t, view, "› 1. sh")
+	assert.Contains(t, view, "2. go")
+	assert.Contains(t, view, "response • 1 lines")
+	assert.Contains(t, view, "echo hello", "The selected block is previewed")
+
+	// When the user moves down and closes it
+	m = pressKey(t, m, 'j')
+	assert.Contains(t, m.View(), "› 2. go")
+	m, _ = pressType(t, m, tea.KeyEsc)
+	assert.Equal(t, ReadMode, m.Mode)
+}
+
+func TestCodeBlockPickerWithoutBlocks(t *testing.T) {
+	m := newSessionModel(t)
+	m.MessagePairs = []MessagePair{{Request: "Hi", Response: "Hello"}}
+
+	m = pressKey(t, m, 'b')
+
+	assert.Equal(t, ReadMode, m.Mode)
+	assert.Contains(t, m.View(), "no code blocks in this message")
+}
+
+func TestCodeBlockPickerCopies(t *testing.T) {
+	copied := fakeClipboard(t, nil)
+	m := pressKey(t, newSessionModel(t, blocksPair), 'b')
+	m = pressKey(t, m, '2')
+
+	m, cmd := pressType(t, m, tea.KeyEnter)
+	require.NotNil(t, cmd)
+	updatedModel, _ := m.Update(cmd())
+	m = updatedModel.(Model)
+
+	assert.Equal(t, `fmt.Println("hello")`, *copied)
+	assert.Contains(t, m.View(), "copied code block 2")
+}
+
+func TestCodeBlockPickerWritesFileConfirmingOverwrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.sh")
+	m := pressKey(t, newSessionModel(t, blocksPair), 'b')
+
+	// When the user writes the first block to a new file
+	m = pressKey(t, m, 'w')
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(path)})
+	m = updatedModel.(Model)
+	m, _ = pressType(t, m, tea.KeyEnter)
+
+	// Then it is written straight away
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "echo hello\n", string(data))
+	assert.Contains(t, m.View(), "wrote "+path)
+
+	// When the user writes the second block to the same path
+	m = pressKey(t, m, 'j')
+	m = pressKey(t, m, 'w')
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(path)})
+	m = updatedModel.(Model)
+	m, _ = pressType(t, m, tea.KeyEnter)
+
+	// Then overwriting is confirmed first
+	assert.Contains(t, m.View(), "exists. Overwrite? y/n")
+	m = pressKey(t, m, 'y')
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fmt.Println(\"hello\")\n", string(data))
+}
+
+func TestCodeBlockPickerRunsBlock(t *testing.T) {
+	m := pressKey(t, newSessionModel(t, blocksPair), 'b')
+
+	// When the user runs the shell block
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	m = updatedModel.(Model)
+
+	// Then they are asked first, shown the block and its runner
+	assert.Nil(t, cmd)
+	view := m.View()
+	assert.Contains(t, view, "echo hello")
+	assert.Contains(t, view, "Run this block with sh? y/n")
+
+	// When they confirm
+	updatedModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updatedModel.(Model)
+	require.NotNil(t, cmd)
+	assert.Contains(t, m.View(), "Running sh")
+	updatedModel, _ = m.Update(cmd())
+	m = updatedModel.(Model)
+
+	// Then its output is shown
+	assert.Equal(t, "hello\n", m.Blocks.Output)
+	assert.Contains(t, m.View(), "enter put the output in the prompt")
+
+	// When the user offers the output to the model
+	m, _ = pressType(t, m, tea.KeyEnter)
+
+	// Then it waits in the prompt as the next request
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "I ran code block 1 (sh). Output:\n\n```\nhello\n```", m.Textarea.Value())
+}
+
+func TestCodeBlockPickerRunDeclined(t *testing.T) {
+	m := pressKey(t, newSessionModel(t, blocksPair), 'b')
+	m = pressKey(t, m, 'x')
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = updatedModel.(Model)
+
+	assert.Nil(t, cmd, "Nothing is run")
+	assert.Equal(t, blockListing, m.Blocks.Stage)
+}
+
+func TestCodeBlockPickerNeedsRunner(t *testing.T) {
+	m := pressKey(t, newSessionModel(t, blocksPair), 'b')
+	m = pressKey(t, m, 'j')
+
+	m = pressKey(t, m, 'x')
+
+	assert.Equal(t, blockListing, m.Blocks.Stage)
+	assert.Contains(t, m.View(), `no runner for "go" code`)
+}
+
+func TestRunBlockStopsChildProcesses(t *testing.T) {
+	// Given a runner whose shell starts a child that outlives the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	start := time.Now()
+
+	// When it is run
+	msg := runBlockCmd(ctx, cancel, "sh", "echo hi; sleep 5; echo bye")().(blockRunMsg)
+
+	// Then it is stopped at the timeout with what it printed so far
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, "hi\n", msg.output)
+	assert.EqualError(t, msg.err, "stopped after 1m0s")
+}
@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"strings"
+
+	"tama/internal/session"
+)
+
+// codeBlock is a fenced code block in a message's markdown
+type codeBlock struct {
+	Lang string // First word of the info string, e.g. "go"; may be empty
+	Code string // Contents, without the fences or a trailing newline
+}
+
+// codeBlocks returns the fenced code blocks in markdown, in order. A fence
+// left open runs to the end of the text, as it would while streaming.
+func codeBlocks(markdown string) []codeBlock {
+	var blocks []codeBlock
+	var f session.Fences
+	var lines []string // Lines of the block we are in
+	for _, line := range strings.Split(markdown, "\n") {
+		switch f.Scan(line) {
+		case session.Opening:
+			lines = nil
+		case session.Code:
+			// The fence's indent is removed from its lines
+			spaces := len(line) - len(strings.TrimLeft(line, " "))
+			lines = append(lines, line[min(spaces, f.Indent()):])
+		case session.Closing:
+			blocks = append(blocks, codeBlock{Lang: fenceLang(f.Info()), Code: strings.Join(lines, "\n")})
+		}
+	}
+	if f.Open() {
+		blocks = append(blocks, codeBlock{Lang: fenceLang(f.Info()), Code: strings.Join(lines, "\n")})
+	}
+	return blocks
+}
+
+// fenceLang returns the language of a block from its fence's info string
+func fenceLang(info string) string {
+	lang, _, _ := strings.Cut(info, " ")
+	return lang
+}
@@ -0,0 +1,32 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []codeBlock
+	}{
+		{"No blocks", "Just text", nil},
+		{
+			"Language tags",
+			"Run:\n\n```bash\ngo test ./...\n```\n\nThen:\n\n~~~go\nfunc main() {\n\n}\n~~~\n",
+			[]codeBlock{{Lang: "bash", Code: "go test ./..."}, {Lang: "go", Code: "func main() {\n\n}"}},
+		},
+		{"No language", "```\nplain\n```", []codeBlock{{Code: "plain"}}},
+		{"Info string after the language", "```python title=x.py\nprint(1)\n```", []codeBlock{{Lang: "python", Code: "print(1)"}}},
+		{"Longer fence holds a shorter one", "````md\n```go\nx\n```\n````", []codeBlock{{Lang: "md", Code: "```go\nx\n```"}}},
+		{"Indented fence", "1. Step\n   ```sh\n   ls\n     -la\n   ```", []codeBlock{{Lang: "sh", Code: "ls\n  -la"}}},
+		{"Unclosed fence", "```go\nfmt.Println(", []codeBlock{{Lang: "go", Code: "fmt.Println("}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, codeBlocks(tt.markdown))
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tama/internal/ollama"
+	"tama/internal/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Command is a slash command typed into the prompt, e.g. "/model qwen3:8b".
+// New commands are added with RegisterCommand.
+type Command struct {
+	Name        string
+	Usage       string // Arguments, e.g. "<path>" or "[name]"
+	Description string
+	// Run executes the command. Returned errors are shown in the status line.
+	Run func(m *Model, args CommandArgs) (tea.Cmd, error)
+	// Complete optionally suggests values for the last argument being typed
+	Complete func(m *Model, args CommandArgs) []string
+}
+
+// CommandArgs holds the text typed after a command name
+type CommandArgs struct {
+	Fields []string // Arguments split on whitespace, honouring quotes
+	Text   string   // Everything after the command name, trimmed
+}
+
+// UsageError describes how the command should be called
+func (c Command) UsageError() error {
+	return fmt.Errorf("usage: /%s %s", c.Name, c.Usage)
+}
+
+var commands = map[string]Command{}
+
+// RegisterCommand adds a slash command, replacing any with the same name
+func RegisterCommand(c Command) {
+	commands[c.Name] = c
+}
+
+// sortedCommands returns the registered commands ordered by name
+func sortedCommands() []Command {
+	list := make([]Command, 0, len(commands))
+	for _, c := range commands {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// parseCommand splits "/name args..." into the command name and arguments.
+// Input starting with "//" is not a command; it is sent with one slash.
+func parseCommand(input string) (string, CommandArgs, bool) {
+	if !strings.HasPrefix(input, "/") || strings.HasPrefix(input, "//") {
+		return "", CommandArgs{}, false
+	}
+	body := input[1:]
+	end := strings.IndexAny(body, " \t\n")
+	if end < 0 {
+		end = len(body)
+	}
+	rest := strings.TrimSpace(body[end:])
+	return body[:end], CommandArgs{Fields: splitArgs(rest), Text: rest}, true
+}
+
+// splitArgs splits on whitespace, keeping single- or double-quoted text
+// together
+func splitArgs(s string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// runCommand executes a slash command typed into the prompt
+func (m Model) runCommand(name string, args CommandArgs) (tea.Model, tea.Cmd) {
+	command, ok := commands[name]
+	if !ok {
+		m.setNotice(fmt.Sprintf("unknown command /%s (try /help)", name), true)
+		return m, nil
+	}
+	input := m.Textarea.Value()
+	m.setPrompt("")
+	cmd, err := command.Run(&m, args)
+	if err != nil {
+		// Keep the input so the command can be corrected
+		m.setPrompt(input)
+		m.setNotice(err.Error(), true)
+	}
+	return m, cmd
+}
+
+// completeCommand completes the command name or argument before the cursor
+// on tab, listing the candidates when there is more than one
+func (m *Model) completeCommand() {
+	input := m.Textarea.Value()
+	name, args, ok := parseCommand(input)
+	if !ok {
+		return
+	}
+
+	var candidates []string
+	var word string
+	if !strings.ContainsAny(input, " \n") {
+		word = name
+		for _, c := range sortedCommands() {
+			if strings.HasPrefix(c.Name, name) {
+				candidates = append(candidates, c.Name)
+			}
+		}
+	} else {
+		command, ok := commands[name]
+		if !ok || command.Complete == nil {
+			return
+		}
+		if !strings.HasSuffix(input, " ") && len(args.Fields) > 0 {
+			word = args.Fields[len(args.Fields)-1]
+		}
+		for _, candidate := range command.Complete(m, args) {
+			if strings.HasPrefix(candidate, word) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return
+	case 1:
+		m.setPrompt(strings.TrimSuffix(input, word) + candidates[0] + " ")
+	default:
+		prefix := commonPrefix(candidates)
+		if len(prefix) > len(word) {
+			m.setPrompt(strings.TrimSuffix(input, word) + prefix)
+		}
+		m.setNotice(strings.Join(candidates, "  "), false)
+	}
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// startCommand puts a command into the prompt, ready for its arguments
+func (m *Model) startCommand(name string) {
+	m.Mode = PromptMode
+	m.Textarea.Focus()
+	m.setPrompt("/" + name + " ")
+}
+
+// openCommandPicker lists every command with its usage
+func (m *Model) openCommandPicker() {
+	m.openPicker(CommandPickerMode, "Commands")
+	var items []pickerItem
+	for _, c := range sortedCommands() {
+		items = append(items, pickerItem{
+			Title:  strings.TrimSpace("/" + c.Name + " " + c.Usage),
+			Detail: c.Description,
+			Value:  c.Name,
+		})
+	}
+	m.Picker.SetItems(items)
+}
+
+// setOption sets a session generation option, or the reasoning setting for
+// "think". An empty value resets it to the default.
+func (m *Model) setOption(name, value string) error {
+	if name != "think" {
+		return m.Options.Set(name, value)
+	}
+	if value == "" {
+		m.Think = ""
+		return nil
+	}
+	think, err := ollama.ParseThink(value)
+	if err != nil {
+		return err
+	}
+	m.Think = think
+	return nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+func init() {
+	RegisterCommand(Command{
+		Name:        "help",
+		Description: "List commands",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			m.openCommandPicker()
+			return nil, nil
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "clear",
+		Description: "Start a new conversation; the current one stays saved",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			m.newSession()
+			return nil, nil
+		},
+	})
+	for _, name := range []string{"exit", "quit"} {
+		RegisterCommand(Command{
+			Name:        name,
+			Description: "Exit tama",
+			Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+				return tea.Quit, nil
+			},
+		})
+	}
+	RegisterCommand(Command{
+		Name:        "model",
+		Usage:       "[name]",
+		Description: "Switch model, or pick one from those installed",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			switch len(args.Fields) {
+			case 0:
+				return m.openModelPicker(), nil
+			case 1:
+				cmd := m.selectModel(args.Fields[0])
+				m.setNotice("model: "+m.CurrentModel, false)
+				return cmd, nil
+			}
+			return nil, commands["model"].UsageError()
+		},
+		Complete: func(m *Model, args CommandArgs) []string {
+			return m.InstalledModels
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "system",
+		Usage:       "[prompt]",
+		Description: "Set the system prompt for this session, or reset it to the default",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			m.SystemPrompt = args.Text
+			m.saveSession()
+			m.updateViewport()
+			switch {
+			case args.Text != "":
+				m.setNotice("system prompt set", false)
+			case m.systemPrompt() != "":
+				m.setNotice("system prompt reset to the default for "+m.CurrentModel, false)
+			default:
+				m.setNotice("system prompt cleared", false)
+			}
+			return nil, nil
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "set",
+		Usage:       "[option [value]]",
+		Description: "Set a generation option for this session; without a value it resets to the default",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			switch len(args.Fields) {
+			case 0:
+				if opts := m.optionsSummary(); opts != "" {
+					m.setNotice(opts, false)
+				} else {
+					m.setNotice("no options set; using the model defaults", false)
+				}
+				return nil, nil
+			case 1, 2:
+				name, value := args.Fields[0], ""
+				if len(args.Fields) == 2 {
+					value = args.Fields[1]
+				}
+				if err := m.setOption(name, value); err != nil {
+					return nil, err
+				}
+				m.saveSession()
+				if value == "" {
+					m.setNotice(name+" reset", false)
+				} else {
+					m.setNotice(name+" set to "+value, false)
+				}
+				return nil, nil
+			}
+			return nil, commands["set"].UsageError()
+		},
+		Complete: func(m *Model, args CommandArgs) []string {
+			if len(args.Fields) > 1 {
+				return nil
+			}
+			return append(ollama.OptionNames(), "think")
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "compact",
+		Description: "Summarize older messages to free up the context window",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			return m.compact()
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "sessions",
+		Description: "Browse saved sessions",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			return m.openSessionPicker(), nil
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "search",
+		Usage:       "<query>",
+		Description: "Search every saved session and open a match",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			if args.Text == "" {
+				return nil, commands["search"].UsageError()
+			}
+			return m.openSessionSearch(args.Text), nil
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "run",
+		Usage:       "<command>",
+		Description: "Run a shell command and send its output as the request",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			if args.Text == "" {
+				return nil, commands["run"].UsageError()
+			}
+			// The same as a request of a ! line for each line of text
+			var lines []string
+			for _, line := range strings.Split(args.Text, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, "!"+line)
+				}
+			}
+			input := strings.Join(lines, "\n")
+			toRun := session.Commands(input)
+			if len(toRun) == 0 {
+				return nil, commands["run"].UsageError()
+			}
+			m.setPrompt(input)
+			m.confirmShell(shellRun{Input: input, Commands: toRun})
+			return nil, nil
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "save",
+		Usage:       "[title]",
+		Description: "Save the conversation now, optionally naming it",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			if len(m.MessagePairs) == 0 {
+				return nil, errors.New("nothing to save yet")
+			}
+			if args.Text != "" {
+				m.Session.Name = args.Text
+			}
+			m.saveSession()
+			m.setNotice("saved session "+m.Session.Title(), false)
+			return nil, nil
+		},
+	})
+	RegisterCommand(Command{
+		Name:        "export",
+		Usage:       "<path>",
+		Description: "Write the conversation to a markdown file",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			if len(args.Fields) != 1 {
+				return nil, commands["export"].UsageError()
+			}
+			if len(m.MessagePairs) == 0 {
+				return nil, errors.New("nothing to export yet")
+			}
+			path := expandHome(args.Fields[0])
+			m.Session.Pairs = m.MessagePairs
+			if err := os.WriteFile(path, []byte(m.Session.Markdown()), 0644); err != nil {
+				return nil, err
+			}
+			m.setNotice("exported to "+path, false)
+			return nil, nil
+		},
+	})
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPromptModeModel(t *testing.T) Model {
+	t.Helper()
+	m := InitialModel()
+	updatedModel, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	return updatedModel.(Model)
+}
+
+// submit types input into the prompt and presses enter
+func submit(t *testing.T, m Model, input string) (Model, tea.Cmd) {
+	t.Helper()
+	m.Textarea.SetValue(input)
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return updatedModel.(Model), cmd
+}
+
+func TestParseCommand(t *testing.T) {
+	name, args, ok := parseCommand(`/save "my session" now`)
+	assert.True(t, ok)
+	assert.Equal(t, "save", name)
+	assert.Equal(t, []string{"my session", "now"}, args.Fields)
+	assert.Equal(t, `"my session" now`, args.Text)
+
+	name, args, ok = parseCommand("/clear")
+	assert.True(t, ok)
+	assert.Equal(t, "clear", name)
+	assert.Empty(t, args.Fields)
+
+	_, _, ok = parseCommand("clear")
+	assert.False(t, ok, "Words without a slash are not commands")
+
+	_, _, ok = parseCommand("//etc/hosts is a file")
+	assert.False(t, ok, "A double slash escapes the command prefix")
+}
+
+func TestPlainWordsAreSentToTheModel(t *testing.T) {
+	// Given a running tama in prompt mode
+	m := newPromptModeModel(t)
+
+	// When the user sends the single word "clear"
+	m, cmd := submit(t, m, "clear")
+
+	// Then it is sent as a request instead of clearing the conversation
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "clear", m.MessagePairs[0].Request)
+	assert.NotNil(t, cmd)
+}
+
+func TestDoubleSlashSendsLiteralSlash(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	m, _ = submit(t, m, "//help me")
+
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "/help me", m.MessagePairs[0].Request)
+}
+
+func TestUnknownCommandShowsErrorAndKeepsInput(t *testing.T) {
+	// Given a running tama in prompt mode
+	m := newPromptModeModel(t)
+
+	// When the user runs an unknown command
+	m, _ = submit(t, m, "/frobnicate")
+
+	// Then an error is shown in the status line
+	assert.Contains(t, m.View(), "unknown command /frobnicate")
+	assert.True(t, m.NoticeIsError)
+
+	// And nothing is sent, with the input kept for correction
+	assert.Empty(t, m.MessagePairs)
+	assert.Equal(t, "/frobnicate", m.Textarea.Value())
+}
+
+func TestUsageErrorShownInStatusLine(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	m, _ = submit(t, m, "/export")
+
+	assert.Contains(t, m.View(), "usage: /export <path>")
+	assert.Equal(t, "/export", m.Textarea.Value())
+}
+
+func TestModelCommandSwitchesModel(t *testing.T) {
+	m := newPromptModeModel(t)
+	m.CurrentModel = "gpt-oss:20b"
+
+	m, cmd := submit(t, m, "/model qwen3:8b")
+
+	assert.Equal(t, "qwen3:8b", m.CurrentModel)
+	assert.NotNil(t, cmd, "Should check whether the model is loaded")
+	assert.Empty(t, m.Textarea.Value())
+}
+
+func TestModelCommandWithoutNameOpensPicker(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	m, _ = submit(t, m, "/model")
+
+	assert.Equal(t, ModelPickerMode, m.Mode)
+}
+
+func TestExitCommandQuits(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	_, cmd := submit(t, m, "/exit")
+
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+}
+
+func TestSaveCommandNamesSession(t *testing.T) {
+	m := newSessionModel(t)
+	m.MessagePairs = []MessagePair{{Request: "What is Go?", Response: "A language"}}
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+
+	m, _ = submit(t, m, "/save Go basics")
+
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Go basics", saved.Title())
+}
+
+func TestExportCommandWritesMarkdown(t *testing.T) {
+	m := newPromptModeModel(t)
+	m.MessagePairs = []MessagePair{{Request: "What is Go?", Response: "A language"}}
+	path := filepath.Join(t.TempDir(), "chat.md")
+
+	m, _ = submit(t, m, "/export "+path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "## Request 1\n\nWhat is Go?")
+	assert.Contains(t, string(data), "A language")
+	assert.Contains(t, m.View(), "exported to")
+}
+
+func TestHelpCommandListsCommands(t *testing.T) {
+	// Given a running tama in prompt mode
+	m := newPromptModeModel(t)
+
+	// When the user runs /help
+	m, _ = submit(t, m, "/help")
+
+	// Then the commands are listed with their usage
+	assert.Equal(t, CommandPickerMode, m.Mode)
+	view := m.View()
+	assert.Contains(t, view, "/export <path>")
+	assert.Contains(t, view, "/model [name]")
+
+	// When the user chooses one
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("expo")})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then it is put into the prompt, ready for arguments
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "/export ", m.Textarea.Value())
+}
+
+func TestTabCompletesCommandNames(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	// A unique prefix completes the command
+	m.Textarea.SetValue("/exp")
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updatedModel.(Model)
+	assert.Equal(t, "/export ", m.Textarea.Value())
+
+	// An ambiguous prefix lists the candidates
+	m.Textarea.SetValue("/s")
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updatedModel.(Model)
+	assert.Contains(t, m.Notice, "save")
+	assert.Contains(t, m.Notice, "sessions")
+}
+
+func TestTabCompletesModelNames(t *testing.T) {
+	m := newPromptModeModel(t)
+	updatedModel, _ := m.Update(modelListMsg{local: testLocalModels})
+	m = updatedModel.(Model)
+
+	m.Textarea.SetValue("/model ll")
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updatedModel.(Model)
+
+	assert.Equal(t, "/model llama3.2:3b ", m.Textarea.Value())
+}
+
+func TestRegisterCommandExtendsRegistry(t *testing.T) {
+	// Given a command registered outside of Update
+	var ran CommandArgs
+	RegisterCommand(Command{
+		Name: "echo-test",
+		Run: func(m *Model, args CommandArgs) (tea.Cmd, error) {
+			ran = args
+			return nil, nil
+		},
+	})
+	t.Cleanup(func() { delete(commands, "echo-test") })
+
+	// When the user runs it
+	m := newPromptModeModel(t)
+	submit(t, m, "/echo-test a 'b c'")
+
+	// Then it receives the parsed arguments
+	assert.Equal(t, []string{"a", "b c"}, ran.Fields)
+}
@@ -0,0 +1,191 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// compactKeep is the number of recent pairs /compact leaves as they are
+const compactKeep = 2
+
+const compactPrompt = `Summarize the conversation you are given so the summary can replace it as context for continuing the conversation. Keep facts, decisions, code, names and open questions; leave out pleasantries. Reply with the summary only.`
+
+// summaryPreamble introduces the summary where it joins the system prompt
+const summaryPreamble = "Summary of the earlier conversation:\n\n"
+
+// compactedMsg carries the summary replacing the first pairs of a session,
+// which are told by when they were requested so that a summary is dropped
+// once the session or branch it was made for is gone
+type compactedMsg struct {
+	session   string      // ID of the session summarized
+	requested []time.Time // RequestedAt of each pair the summary replaces
+	summary   string
+	err       error
+}
+
+// summary returns the summary of compacted pairs, if any
+func (m Model) summary() string {
+	if m.Session == nil {
+		return ""
+	}
+	return m.Session.Summary
+}
+
+// requestSystemPrompt is the system message sent with a request: the system
+// prompt followed by the summary of compacted pairs
+func (m Model) requestSystemPrompt() string {
+	system := m.systemPrompt()
+	if summary := m.summary(); summary != "" {
+		system = strings.TrimSpace(system + "\n\n" + summaryPreamble + summary)
+	}
+	return system
+}
+
+// compact asks the current model to summarize every pair but the most
+// recent, folding in any earlier summary
+func (m *Model) compact() (tea.Cmd, error) {
+	if m.Compacting {
+		return nil, errors.New("already compacting")
+	}
+	upTo := max(len(m.MessagePairs)-compactKeep, 0)
+	var transcript strings.Builder
+	if summary := m.summary(); summary != "" {
+		fmt.Fprintf(&transcript, "Summary so far:\n%s\n\n", summary)
+	}
+	pending := 0
+	for _, pair := range m.MessagePairs[:upTo] {
+		if pair.Summarized || pair.Cancelled || pair.Error != "" || pair.Response == "" {
+			continue
+		}
+		fmt.Fprintf(&transcript, "User: %s\n\nAssistant: %s\n\n", pair.Prompt(), pair.Response)
+		pending++
+	}
+	if pending == 0 {
+		return nil, errors.New("nothing to compact yet")
+	}
+
+	requested := make([]time.Time, upTo)
+	for i, pair := range m.MessagePairs[:upTo] {
+		requested[i] = pair.RequestedAt
+	}
+
+	m.Compacting = true
+	m.setNotice(fmt.Sprintf("compacting %d pairs…", pending), false)
+	req := ollama.ChatRequest{
+		Model: m.CurrentModel,
+		Messages: []ollama.Message{
+			{Role: "system", Content: compactPrompt},
+			{Role: "user", Content: transcript.String()},
+		},
+	}
+	if opts := m.options(); !opts.IsZero() {
+		req.Options = &opts
+	}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	m.cancelCompactFn = cancelFn
+	return compactCmd(m.Client, req, ctx, cancelFn, compactedMsg{session: m.sessionID(), requested: requested}), nil
+}
+
+// compactCmd streams the summary into done, which names what it summarizes
+func compactCmd(client *ollama.Client, req ollama.ChatRequest, ctx context.Context, cancelFn func(), done compactedMsg) tea.Cmd {
+	return func() tea.Msg {
+		defer cancelFn()
+		chunks, err := client.Chat(ctx, req)
+		if err != nil {
+			done.err = err
+			return done
+		}
+		var summary strings.Builder
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				done.err = chunk.Err
+				return done
+			}
+			summary.WriteString(chunk.Message.Content)
+		}
+		done.summary = strings.TrimSpace(summary.String())
+		return done
+	}
+}
+
+// stopCompaction cancels the compaction in progress, whose summary would be
+// for a conversation no longer shown
+func (m *Model) stopCompaction() {
+	if m.cancelCompactFn != nil {
+		m.cancelCompactFn()
+		m.cancelCompactFn = nil
+	}
+	m.Compacting = false
+}
+
+// compactionIsCurrent reports whether msg summarizes the first pairs of the
+// conversation shown
+func (m Model) compactionIsCurrent(msg compactedMsg) bool {
+	if msg.session != m.sessionID() || len(msg.requested) > len(m.MessagePairs) {
+		return false
+	}
+	for i, requestedAt := range msg.requested {
+		if !m.MessagePairs[i].RequestedAt.Equal(requestedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyCompaction marks the summarized pairs and keeps the summary with the
+// session; the pairs stay in the session for reading
+func (m *Model) applyCompaction(msg compactedMsg) {
+	if msg.session != m.sessionID() || errors.Is(msg.err, context.Canceled) {
+		// Stopped when its session was left
+		return
+	}
+	m.Compacting = false
+	m.cancelCompactFn = nil
+	if msg.err != nil {
+		m.setNotice(fmt.Sprintf("compaction failed: %v", msg.err), true)
+		return
+	}
+	if !m.compactionIsCurrent(msg) {
+		m.setNotice("compaction dropped: the conversation changed while it ran", true)
+		return
+	}
+	if msg.summary == "" {
+		m.setNotice("compaction failed: the model returned no summary", true)
+		return
+	}
+	count := 0
+	for i := range msg.requested {
+		if !m.MessagePairs[i].Summarized {
+			m.MessagePairs[i].Summarized = true
+			count++
+		}
+	}
+	m.Session.Summary = msg.summary
+	m.saveSession()
+	m.updateViewport()
+	m.setNotice(fmt.Sprintf("summarized %d pairs", count), false)
+}
+
+// autoCompact starts compaction when context usage reaches the configured
+// threshold
+func (m *Model) autoCompact() tea.Cmd {
+	threshold := m.Config.Context.CompactAt
+	if threshold <= 0 || m.Compacting {
+		return nil
+	}
+	if _, percent := m.contextUsage(); percent < threshold {
+		return nil
+	}
+	cmd, err := m.compact()
+	if err != nil {
+		return nil
+	}
+	return cmd
+}
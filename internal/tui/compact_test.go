@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tama/internal/config"
+	"tama/internal/ollama"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCompactServer fakes Ollama answering every chat with summary,
+// recording the request
+func newCompactServer(t *testing.T, summary string, received *ollama.ChatRequest) *ollama.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(received))
+		fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":true}`+"\n", summary)
+	}))
+	t.Cleanup(server.Close)
+	return ollama.NewClient(server.URL)
+}
+
+// fourPairs is a conversation of four completed pairs
+var fourPairs = []MessagePair{
+	{Request: "My name is Ada", Response: "Hello Ada"},
+	{Request: "I like Go", Response: "Go is nice"},
+	{Request: "What is a slice?", Response: "A view of an array"},
+	{Request: "And a map?", Response: "A hash table"},
+}
+
+func TestCompactSummarizesOlderPairs(t *testing.T) {
+	// Given a conversation of four pairs
+	var received ollama.ChatRequest
+	m := pressKey(t, newSessionModel(t, fourPairs...), 'i')
+	m.Client = newCompactServer(t, "The user is Ada, who likes Go.", &received)
+
+	// When the user runs /compact
+	m, cmd := submit(t, m, "/compact")
+	require.NotNil(t, cmd)
+	assert.True(t, m.Compacting)
+	updatedModel, _ := m.Update(cmd())
+	m = updatedModel.(Model)
+
+	// Then the model was asked to summarize all but the last two pairs
+	require.Len(t, received.Messages, 2)
+	assert.Equal(t, "system", received.Messages[0].Role)
+	assert.Contains(t, received.Messages[1].Content, "User: I like Go\n\nAssistant: Go is nice")
+	assert.NotContains(t, received.Messages[1].Content, "slice")
+
+	// And those pairs are marked summarized but kept
+	require.Len(t, m.MessagePairs, 4)
+	assert.True(t, m.MessagePairs[0].Summarized)
+	assert.True(t, m.MessagePairs[1].Summarized)
+	assert.False(t, m.MessagePairs[2].Summarized)
+	assert.Contains(t, m.Notice, "summarized 2 pairs")
+
+	// And requests send the summary in their place
+	req := m.newChatRequest(m.MessagePairs)
+	require.Len(t, req.Messages, 5)
+	assert.Equal(t, "system", req.Messages[0].Role)
+	assert.Contains(t, req.Messages[0].Content, "The user is Ada, who likes Go.")
+	assert.Equal(t, "What is a slice?", req.Messages[1].Content)
+
+	// And the summary is saved with the session
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "The user is Ada, who likes Go.", saved.Summary)
+	assert.True(t, saved.Pairs[0].Summarized)
+}
+
+func TestSummarizedPairsAreMarkedInViewport(t *testing.T) {
+	m := pressKey(t, newSessionModel(t, fourPairs...), 'i')
+	m.applyCompaction(compactedMsg{session: m.Session.ID, requested: make([]time.Time, 2), summary: "Ada likes Go."})
+
+	m.CurrentPairIndex = 0
+	m.updateViewport()
+	assert.Contains(t, m.Viewport.View(), "Summarized: replaced by the conversation summary")
+
+	m.CurrentPairIndex = 2
+	m.updateViewport()
+	view := m.Viewport.View()
+	assert.NotContains(t, view, "Summarized:")
+	assert.Contains(t, view, "Summary (s to expand)")
+	assert.Contains(t, view, "Ada likes Go.")
+}
+
+func TestCompactionForAnotherConversationIsDropped(t *testing.T) {
+	// Given a conversation being compacted
+	var received ollama.ChatRequest
+	m := pressKey(t, newSessionModel(t, fourPairs...), 'i')
+	m.Client = newCompactServer(t, "Ada likes Go.", &received)
+	m, cmd := submit(t, m, "/compact")
+	require.NotNil(t, cmd)
+	done := cmd()
+
+	// When the user switches to another branch of the conversation before
+	// the summary arrives
+	m.MessagePairs[1] = MessagePair{Request: "I like Rust", Response: "Rust is nice", RequestedAt: time.Now()}
+	updatedModel, _ := m.Update(done)
+	m = updatedModel.(Model)
+
+	// Then the summary is dropped and no pair is marked
+	assert.False(t, m.Compacting)
+	assert.Empty(t, m.Session.Summary)
+	assert.False(t, m.MessagePairs[0].Summarized)
+	assert.Contains(t, m.Notice, "the conversation changed")
+}
+
+func TestClearStopsCompaction(t *testing.T) {
+	// Given a conversation being compacted
+	m := pressKey(t, newSessionModel(t, fourPairs...), 'i')
+	m.Client = newCompactServer(t, "Ada likes Go.", &ollama.ChatRequest{})
+	m, cmd := submit(t, m, "/compact")
+	require.NotNil(t, cmd)
+	oldID := m.Session.ID
+
+	// When the user clears the conversation
+	m, _ = submit(t, m, "/clear")
+
+	// Then the compaction is stopped
+	assert.False(t, m.Compacting)
+	done, ok := cmd().(compactedMsg)
+	require.True(t, ok)
+	assert.ErrorIs(t, done.err, context.Canceled)
+
+	// And a summary arriving anyway is kept out of the new session
+	done = compactedMsg{session: oldID, requested: make([]time.Time, 2), summary: "Ada likes Go."}
+	updatedModel, _ := m.Update(done)
+	m = updatedModel.(Model)
+	assert.Empty(t, m.Session.Summary)
+	assert.Empty(t, m.Notice)
+}
+
+func TestCompactWithNothingToSummarize(t *testing.T) {
+	m := pressKey(t, newSessionModel(t, fourPairs...), 'i')
+	m.MessagePairs = m.MessagePairs[:2]
+
+	m, cmd := submit(t, m, "/compact")
+
+	assert.Nil(t, cmd)
+	assert.True(t, m.NoticeIsError)
+	assert.Equal(t, "nothing to compact yet", m.Notice)
+}
+
+func TestAutoCompactAtThreshold(t *testing.T) {
+	// Given auto compaction at 50% of a small context window
+	m := pressKey(t, newSessionModel(t, fourPairs...), 'i')
+	m.Config.Context = config.ContextConfig{CompactAt: 50}
+	m.ContextLength = 100
+	var received ollama.ChatRequest
+	m.Client = newCompactServer(t, "Summary", &received)
+
+	// When a response completes past the threshold
+	m, _ = submit(t, m, "Tell me more about maps")
+	updatedModel, cmd := m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Maps are unordered."})
+	m = updatedModel.(Model)
+
+	// Then compaction starts
+	require.NotNil(t, cmd)
+	assert.True(t, m.Compacting)
+
+	// But not when below it
+	m = pressKey(t, newSessionModel(t, fourPairs...), 'i')
+	m.Config.Context = config.ContextConfig{CompactAt: 50}
+	m, _ = submit(t, m, "Tell me more about maps")
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Maps are unordered."})
+	assert.False(t, updatedModel.(Model).Compacting)
+}
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"tama/internal/config"
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultNumCtx is the context length Ollama uses when neither the model
+// nor the options set num_ctx
+const defaultNumCtx = 4096
+
+// contextSizeMsg reports the num_ctx a model's Modelfile sets (0 if none)
+type contextSizeMsg struct {
+	model  string
+	numCtx int
+}
+
+func contextSizeCmd(client *ollama.Client, model string) tea.Cmd {
+	return func() tea.Msg {
+		show, err := client.Show(context.Background(), model)
+		if err != nil {
+			// Unknown models fall back to the default; sending reports the error
+			return contextSizeMsg{model: model}
+		}
+		return contextSizeMsg{model: model, numCtx: show.NumCtx()}
+	}
+}
+
+// numCtx is the context length of the next request: num_ctx from the
+// options, else the model's own, else Ollama's default
+func (m Model) numCtx() int {
+	if n := m.options().NumCtx; n != nil && *n > 0 {
+		return *n
+	}
+	if m.ContextLength > 0 {
+		return m.ContextLength
+	}
+	return defaultNumCtx
+}
+
+// contextBudget is the share of the context the conversation may fill,
+// leaving a quarter for the reply
+func (m Model) contextBudget() int {
+	return m.numCtx() * 3 / 4
+}
+
+// estimateTokens approximates a message's token count at four characters
+// per token, plus a few for the chat template around it
+func estimateTokens(text string) int {
+	return utf8.RuneCountInString(text)/4 + 4
+}
+
+func pairTokens(pair MessagePair) int {
+	tokens := estimateTokens(pair.Prompt())
+	if pair.Response != "" {
+		tokens += estimateTokens(pair.Response)
+	}
+	return tokens
+}
+
+// contextPlan decides which pairs are sent so the conversation fits the
+// context window, using the configured strategy. Cancelled, failed and
+// summarized pairs are never sent; the last pair, being the new request,
+// always is.
+// tokens estimates the size of what is sent, including the system prompt.
+func (m Model) contextPlan(pairs []MessagePair) (sent []bool, tokens int) {
+	sent = make([]bool, len(pairs))
+	if prompt := m.requestSystemPrompt(); prompt != "" {
+		tokens = estimateTokens(prompt)
+	}
+	for i, pair := range pairs {
+		sent[i] = !pair.Cancelled && pair.Error == "" && !pair.Summarized
+	}
+
+	cfg := m.Config.Context
+	strategy := cfg.StrategyOrDefault()
+	if strategy == config.StrategySliding {
+		for i := 0; i < len(pairs)-cfg.WindowOrDefault(); i++ {
+			sent[i] = false
+		}
+	}
+	for i, pair := range pairs {
+		if sent[i] {
+			tokens += pairTokens(pair)
+		}
+	}
+	if strategy == config.StrategyNone {
+		return sent, tokens
+	}
+
+	keep := 0
+	if strategy == config.StrategyKeepFirst {
+		keep = cfg.KeepFirstOrDefault()
+	}
+	budget := m.contextBudget()
+	for i := keep; i < len(pairs)-1 && tokens > budget; i++ {
+		if sent[i] {
+			sent[i] = false
+			tokens -= pairTokens(pairs[i])
+		}
+	}
+	return sent, tokens
+}
+
+// contextPairs returns the pairs sent with the next request
+func (m Model) contextPairs(pairs []MessagePair) []MessagePair {
+	sent, _ := m.contextPlan(pairs)
+	var included []MessagePair
+	for i, pair := range pairs {
+		if sent[i] {
+			included = append(included, pair)
+		}
+	}
+	return included
+}
+
+// contextUsage estimates the tokens sent with the conversation so far and
+// the percentage of the context window they fill
+func (m Model) contextUsage() (tokens, percent int) {
+	_, tokens = m.contextPlan(m.MessagePairs)
+	return tokens, tokens * 100 / m.numCtx()
+}
+
+// contextGauge shows how full the context window is, e.g.
+// "CTX ███░░░░░ 41%"
+func (m Model) contextGauge() string {
+	if len(m.MessagePairs) == 0 {
+		return ""
+	}
+	tokens, percent := m.contextUsage()
+	const width = 8
+	filled := min(tokens*width/m.numCtx(), width)
+	return fmt.Sprintf("CTX %s%s %d%%", strings.Repeat("█", filled), strings.Repeat("░", width-filled), percent)
+}
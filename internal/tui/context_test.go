@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"tama/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// longPairs makes n pairs of roughly 100 estimated tokens each
+func longPairs(n int) []MessagePair {
+	pairs := make([]MessagePair, n)
+	for i := range pairs {
+		pairs[i] = MessagePair{
+			Request:  strings.Repeat("q", 200) + string(rune('A'+i)),
+			Response: strings.Repeat("a", 180),
+		}
+	}
+	return pairs
+}
+
+func TestContextPlanStrategies(t *testing.T) {
+	m := newPromptModeModel(t)
+	// A 400 token context leaves a 300 token budget: two pairs
+	m.ContextLength = 400
+	pairs := longPairs(6)
+
+	tests := []struct {
+		context config.ContextConfig
+		sent    []bool
+	}{
+		{config.ContextConfig{}, []bool{false, false, false, false, true, true}},
+		{config.ContextConfig{Strategy: config.StrategyKeepFirst}, []bool{true, false, false, false, false, true}},
+		{config.ContextConfig{Strategy: config.StrategyKeepFirst, KeepFirst: 2}, []bool{true, true, false, false, false, true}},
+		{config.ContextConfig{Strategy: config.StrategySliding, Window: 2}, []bool{false, false, false, false, true, true}},
+		{config.ContextConfig{Strategy: config.StrategyNone}, []bool{true, true, true, true, true, true}},
+	}
+	for _, tt := range tests {
+		m.Config.Context = tt.context
+		sent, _ := m.contextPlan(pairs)
+		assert.Equal(t, tt.sent, sent, "strategy %+v", tt.context)
+	}
+}
+
+func TestContextPlanSkipsCancelledAndAlwaysSendsLastPair(t *testing.T) {
+	m := newPromptModeModel(t)
+	m.ContextLength = 100
+	pairs := longPairs(3)
+	pairs[1].Cancelled = true
+
+	sent, tokens := m.contextPlan(pairs)
+
+	assert.Equal(t, []bool{false, false, true}, sent, "The new request is sent even if it alone overflows")
+	assert.Equal(t, pairTokens(pairs[2]), tokens)
+}
+
+func TestNumCtxPrecedence(t *testing.T) {
+	m := newPromptModeModel(t)
+	assert.Equal(t, defaultNumCtx, m.numCtx())
+
+	updatedModel, _ := m.Update(contextSizeMsg{model: m.CurrentModel, numCtx: 8192})
+	m = updatedModel.(Model)
+	assert.Equal(t, 8192, m.numCtx(), "The model's num_ctx replaces the default")
+
+	updatedModel, _ = m.Update(contextSizeMsg{model: "some-other-model", numCtx: 2048})
+	m = updatedModel.(Model)
+	assert.Equal(t, 8192, m.numCtx(), "Sizes for other models are ignored")
+
+	require.NoError(t, m.Options.Set("num_ctx", "16384"))
+	assert.Equal(t, 16384, m.numCtx(), "num_ctx from the options wins")
+}
+
+func TestDroppedPairsAreMarkedAndNotSent(t *testing.T) {
+	// Given a conversation that has outgrown a small context window
+	m := newReadModeModel(t)
+	m.ContextLength = 400
+	m.MessagePairs = longPairs(5)
+	m.CurrentPairIndex = 0
+	m.updateViewport()
+
+	// Then the oldest pair is marked as outside the window
+	assert.Contains(t, m.Viewport.View(), "Outside the context window")
+
+	// And left out of the next request
+	req := m.newChatRequest(m.MessagePairs)
+	assert.Len(t, req.Messages, 4, "Two pairs of user and assistant messages fit")
+	assert.True(t, strings.HasSuffix(req.Messages[0].Content, "D"))
+
+	// And the gauge shows the window filling up
+	assert.Contains(t, m.View(), "CTX ████")
+
+	// But recent pairs are not marked
+	m.CurrentPairIndex = 4
+	m.updateViewport()
+	assert.NotContains(t, m.Viewport.View(), "Outside the context window")
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorFinishedMsg carries the text saved in the external editor
+type editorFinishedMsg struct {
+	text string
+	send bool // Whether to send the text rather than return it to the prompt
+	err  error
+}
+
+// editorCommand returns the user's editor: $VISUAL, else $EDITOR, else vi.
+// The variable may include arguments, e.g. "code --wait".
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// openEditor suspends the program and edits text in the external editor.
+// When it exits, the saved text is sent if send is set, else put back in
+// the prompt.
+func openEditor(text string, send bool) tea.Cmd {
+	file, err := os.CreateTemp("", "tama-prompt-*.md")
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return func() tea.Msg { return editorFinishedMsg{err: err} }
+	}
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return readEdited(file.Name(), send, err)
+	})
+}
+
+// readEdited reads and removes the file the editor saved
+func readEdited(path string, send bool, err error) tea.Msg {
+	defer os.Remove(path)
+	if err != nil {
+		return editorFinishedMsg{err: fmt.Errorf("editor: %w", err)}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return editorFinishedMsg{err: err}
+	}
+	return editorFinishedMsg{text: strings.TrimRight(string(data), "\n"), send: send}
+}
+
+// finishEditing uses the text saved in the editor: sent as a request, or
+// staged in the prompt for more editing
+func (m Model) finishEditing(msg editorFinishedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.setNotice(msg.err.Error(), true)
+		return m, nil
+	}
+	if msg.send && strings.TrimSpace(msg.text) == "" {
+		// As with git commit, an empty file aborts
+		m.setNotice("nothing sent: the editor saved no text", false)
+		return m, nil
+	}
+	m.Mode = PromptMode
+	m.Textarea.Focus()
+	m.setPrompt(msg.text)
+	if msg.send && !m.IsWaiting && !m.ChatRequested {
+		return m.submitPrompt()
+	}
+	return m, nil
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, []string{"vi"}, editorCommand())
+
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, []string{"nano"}, editorCommand())
+
+	t.Setenv("VISUAL", "code --wait")
+	assert.Equal(t, []string{"code", "--wait"}, editorCommand(), "$VISUAL wins and may have arguments")
+}
+
+func TestReadEditedRemovesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.md")
+	require.NoError(t, os.WriteFile(path, []byte("Explain this:\n\n  indented\n"), 0600))
+
+	msg := readEdited(path, true, nil)
+
+	assert.Equal(t, editorFinishedMsg{text: "Explain this:\n\n  indented", send: true}, msg)
+	assert.NoFileExists(t, path)
+}
+
+func TestCtrlXCtrlEOpensEditor(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	m := newPromptModeModel(t)
+	m.Textarea.SetValue("draft")
+
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	m = updatedModel.(Model)
+	assert.Nil(t, cmd)
+	assert.True(t, m.PendingCtrlX)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+
+	assert.NotNil(t, cmd, "Should suspend the program for the editor")
+	matches, _ := filepath.Glob(filepath.Join(os.Getenv("TMPDIR"), "tama-prompt-*.md"))
+	require.Len(t, matches, 1)
+	data, _ := os.ReadFile(matches[0])
+	assert.Equal(t, "draft", string(data), "The editor starts with the current input")
+}
+
+func TestEditedTextIsStagedInPrompt(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	updatedModel, _ := m.Update(editorFinishedMsg{text: "line one\nline two"})
+	m = updatedModel.(Model)
+
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "line one\nline two", m.Textarea.Value())
+	assert.Empty(t, m.MessagePairs, "Staged text waits for Enter")
+}
+
+func TestEditedTextIsSentFromReadMode(t *testing.T) {
+	m := newSessionModel(t)
+
+	updatedModel, cmd := m.Update(editorFinishedMsg{text: "A long\nstructured prompt", send: true})
+	m = updatedModel.(Model)
+
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "A long\nstructured prompt", m.MessagePairs[0].Request)
+	assert.NotNil(t, cmd)
+	assert.Empty(t, m.Textarea.Value())
+}
+
+func TestEditorErrorsAndEmptyTextSendNothing(t *testing.T) {
+	m := newSessionModel(t)
+
+	updatedModel, _ := m.Update(editorFinishedMsg{text: "  \n", send: true})
+	m = updatedModel.(Model)
+	assert.Empty(t, m.MessagePairs)
+	assert.Contains(t, m.View(), "nothing sent")
+
+	updatedModel, _ = m.Update(editorFinishedMsg{err: errors.New("editor: exit status 1")})
+	m = updatedModel.(Model)
+	assert.Empty(t, m.MessagePairs)
+	assert.True(t, m.NoticeIsError)
+}
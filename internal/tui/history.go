@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// recall is the place in the history while Up and Down cycle through it
+type recall struct {
+	Prompts []string // Earlier prompts, newest first; nil when not cycling
+	Index   int      // Prompt shown in the textarea
+}
+
+// recordPrompt adds a sent prompt to the history
+func (m *Model) recordPrompt(text string) {
+	m.Recall = recall{}
+	if m.History == nil {
+		return
+	}
+	if err := m.History.Add(text, m.sessionID()); err != nil {
+		m.Err = fmt.Errorf("failed to save prompt history: %w", err)
+	}
+}
+
+func (m Model) sessionID() string {
+	if m.Session == nil {
+		return ""
+	}
+	return m.Session.ID
+}
+
+// historyPrompts returns the earlier prompts of every session, or of this
+// one, newest first
+func (m Model) historyPrompts(global bool) []string {
+	if m.History == nil {
+		return nil
+	}
+	if global {
+		return m.History.Prompts("")
+	}
+	if m.sessionID() == "" {
+		return nil
+	}
+	return m.History.Prompts(m.sessionID())
+}
+
+// recallPrompt replaces the prompt with an older (Up) or newer (Down) one
+// from the history when the key would otherwise go nowhere: from an empty
+// prompt, or from the first or last row of a recalled prompt left as it
+// was. It reports whether it did; otherwise the key moves the cursor.
+func (m *Model) recallPrompt(older bool) bool {
+	value := m.Textarea.Value()
+	cycling := m.Recall.Prompts != nil && value == m.Recall.Prompts[m.Recall.Index]
+	switch {
+	case value == "" && older:
+		prompts := m.historyPrompts(m.Config.Input.HistoryIsGlobal())
+		if len(prompts) == 0 {
+			return false
+		}
+		m.Recall = recall{Prompts: prompts}
+	case !cycling:
+		return false
+	case older:
+		if !m.cursorOnFirstRow() || m.Recall.Index == len(m.Recall.Prompts)-1 {
+			return false
+		}
+		m.Recall.Index++
+	default:
+		if !m.cursorOnLastRow() {
+			return false
+		}
+		if m.Recall.Index == 0 {
+			// Past the newest prompt, back to an empty one
+			m.Recall = recall{}
+			m.setPrompt("")
+			return true
+		}
+		m.Recall.Index--
+	}
+	m.setPrompt(m.Recall.Prompts[m.Recall.Index])
+	return true
+}
+
+func (m Model) cursorOnFirstRow() bool {
+	return m.Textarea.Line() == 0 && m.Textarea.LineInfo().RowOffset == 0
+}
+
+func (m Model) cursorOnLastRow() bool {
+	info := m.Textarea.LineInfo()
+	return m.Textarea.Line() == m.Textarea.LineCount()-1 && info.RowOffset == info.Height-1
+}
+
+// openHistoryPicker lists earlier prompts, newest first, filtered by what
+// is typed, starting with query
+func (m *Model) openHistoryPicker(query string, global bool) {
+	title := "History: this session (tab for all sessions)"
+	if global {
+		title = "History: all sessions (tab for this session)"
+	}
+	m.openPicker(HistoryPickerMode, title)
+	m.HistoryGlobal = global
+
+	var items []pickerItem
+	for _, prompt := range m.historyPrompts(global) {
+		item := pickerItem{Title: strings.ReplaceAll(prompt, "\n", " ↵ "), Value: prompt}
+		if lines := strings.Count(prompt, "\n") + 1; lines > 1 {
+			item.Detail = fmt.Sprintf("%d lines", lines)
+		}
+		items = append(items, item)
+	}
+	m.Picker.Filter.SetValue(query)
+	m.Picker.SetItems(items)
+}
@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"testing"
+
+	"tama/internal/config"
+	"tama/internal/history"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentPromptsAreRecorded(t *testing.T) {
+	// Given a prompt with an empty history
+	m := pressKey(t, newSessionModel(t), 'i')
+
+	// When the user sends a request and a command
+	m, _ = submit(t, m, "What is Go?")
+	m.IsWaiting, m.ChatRequested = false, false
+	m.Mode = PromptMode
+	m.Textarea.Focus()
+	m, _ = submit(t, m, "/system Be brief")
+
+	// Then both are saved with the session they were sent in
+	loaded, err := history.Load(m.History.Path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Entries, 2)
+	assert.Equal(t, "What is Go?", loaded.Entries[0].Text)
+	assert.Equal(t, m.Session.ID, loaded.Entries[0].Session)
+	assert.Equal(t, "/system Be brief", loaded.Entries[1].Text)
+}
+
+func TestUpAndDownCyclePreviousPrompts(t *testing.T) {
+	// Given a history of three prompts, one sent twice
+	m := pressKey(t, newSessionModel(t), 'i')
+	for _, prompt := range []string{"first", "second", "first", "third"} {
+		require.NoError(t, m.History.Add(prompt, "other"))
+	}
+
+	// When the user presses up in the empty prompt
+	m, _ = pressType(t, m, tea.KeyUp)
+
+	// Then the newest prompt is recalled, then older ones once each
+	assert.Equal(t, "third", m.Textarea.Value())
+	m, _ = pressType(t, m, tea.KeyUp)
+	assert.Equal(t, "first", m.Textarea.Value())
+	m, _ = pressType(t, m, tea.KeyUp)
+	assert.Equal(t, "second", m.Textarea.Value())
+	m, _ = pressType(t, m, tea.KeyUp)
+	assert.Equal(t, "second", m.Textarea.Value(), "The oldest prompt stays")
+
+	// When the user presses down past the newest
+	m, _ = pressType(t, m, tea.KeyDown)
+	assert.Equal(t, "first", m.Textarea.Value())
+	m, _ = pressType(t, m, tea.KeyDown)
+	m, _ = pressType(t, m, tea.KeyDown)
+
+	// Then the prompt is empty again
+	assert.Equal(t, "", m.Textarea.Value())
+}
+
+func TestUpMovesTheCursorOnceThePromptChanges(t *testing.T) {
+	// Given a history with a multi-line prompt, recalled into the prompt
+	m := pressKey(t, newSessionModel(t), 'i')
+	require.NoError(t, m.History.Add("older", ""))
+	require.NoError(t, m.History.Add("line one\nline two", ""))
+	m, _ = pressType(t, m, tea.KeyUp)
+	require.Equal(t, "line one\nline two", m.Textarea.Value())
+
+	// When the user presses up from its last line
+	m, _ = pressType(t, m, tea.KeyUp)
+
+	// Then the cursor moves to the first line rather than recalling
+	assert.Equal(t, "line one\nline two", m.Textarea.Value())
+	assert.Equal(t, 0, m.Textarea.Line())
+
+	// When the user edits it and presses up again
+	m = typeText(t, m, "X")
+	m, _ = pressType(t, m, tea.KeyUp)
+
+	// Then the edited text is kept
+	assert.Contains(t, m.Textarea.Value(), "X")
+
+	// And from a prompt with text typed, up does not recall either
+	m.setPrompt("draft")
+	m, _ = pressType(t, m, tea.KeyUp)
+	assert.Equal(t, "draft", m.Textarea.Value())
+}
+
+func TestHistoryScopedToSession(t *testing.T) {
+	// Given prompts from this session and another, with history per session
+	m := pressKey(t, newSessionModel(t), 'i')
+	m.Config.Input.History = config.HistorySession
+	require.NoError(t, m.History.Add("elsewhere", "other"))
+	require.NoError(t, m.History.Add("here", m.Session.ID))
+	require.NoError(t, m.History.Add("elsewhere later", "other"))
+
+	// When the user presses up twice
+	m, _ = pressType(t, m, tea.KeyUp)
+	assert.Equal(t, "here", m.Textarea.Value())
+	m, _ = pressType(t, m, tea.KeyUp)
+
+	// Then only this session's prompt is recalled
+	assert.Equal(t, "here", m.Textarea.Value())
+}
+
+func TestCtrlRSearchesHistory(t *testing.T) {
+	// Given a history of prompts
+	m := pressKey(t, newSessionModel(t), 'i')
+	require.NoError(t, m.History.Add("explain rust lifetimes", "other"))
+	require.NoError(t, m.History.Add("write a haiku", m.Session.ID))
+	require.NoError(t, m.History.Add("rust borrow checker\nin detail", "other"))
+
+	// When the user presses ctrl+r and types part of a prompt
+	m, _ = pressType(t, m, tea.KeyCtrlR)
+	require.Equal(t, HistoryPickerMode, m.Mode)
+	m = typeText(t, m, "rust")
+
+	// Then the matching prompts are listed newest first
+	view := m.View()
+	assert.Contains(t, view, "History: all sessions")
+	assert.Contains(t, view, "› rust borrow checker ↵ in detail")
+	assert.Contains(t, view, "2 lines")
+	assert.Contains(t, view, "explain rust lifetimes")
+	assert.NotContains(t, view, "haiku")
+
+	// When the user presses ctrl+r again and enter
+	m, _ = pressType(t, m, tea.KeyCtrlR)
+	m, _ = pressType(t, m, tea.KeyEnter)
+
+	// Then the next older match is put in the prompt, not sent
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "explain rust lifetimes", m.Textarea.Value())
+	assert.Empty(t, m.MessagePairs)
+}
+
+func TestCtrlRTabSwitchesScope(t *testing.T) {
+	// Given prompts from this session and another, and text in the prompt
+	m := pressKey(t, newSessionModel(t), 'i')
+	require.NoError(t, m.History.Add("write a haiku", m.Session.ID))
+	require.NoError(t, m.History.Add("write a sonnet", "other"))
+	m.setPrompt("write")
+
+	// When the user searches and presses tab
+	m, _ = pressType(t, m, tea.KeyCtrlR)
+	assert.Contains(t, m.View(), "sonnet", "The search starts from the prompt's text")
+	m, _ = pressType(t, m, tea.KeyTab)
+
+	// Then only this session's prompts are listed, with the same filter
+	view := m.View()
+	assert.Contains(t, view, "History: this session")
+	assert.Contains(t, view, "haiku")
+	assert.NotContains(t, view, "sonnet")
+	assert.Equal(t, "write", m.Picker.Filter.Value())
+
+	// When the user closes the search
+	m, _ = pressType(t, m, tea.KeyEsc)
+
+	// Then the prompt is as it was
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "write", m.Textarea.Value())
+}
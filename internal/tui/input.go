@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// isSubmitKey reports whether key sends the prompt
+func (m Model) isSubmitKey(msg tea.KeyMsg) bool {
+	return slices.Contains(m.Config.Input.SubmitKeys(), msg.String())
+}
+
+// isNewlineKey reports whether key breaks the prompt's line
+func (m Model) isNewlineKey(msg tea.KeyMsg) bool {
+	return slices.Contains(m.Config.Input.NewlineKeys(), msg.String())
+}
+
+// setPrompt replaces the text in the prompt, leaving the cursor at its end
+func (m *Model) setPrompt(text string) {
+	if text != m.Textarea.Value() {
+		m.forgetOversize()
+	}
+	m.Textarea.SetValue(text)
+	m.fitPrompt()
+}
+
+// fitPrompt sizes the prompt to its text, up to the configured height; past
+// that the textarea scrolls to keep the cursor in view
+func (m *Model) fitPrompt() {
+	limit := m.Config.Input.MaxHeightOrDefault()
+	if m.Height > 0 {
+		// Leave room for the viewport's minimum height of 5 and the rest of
+		// the layout (see calculateViewportHeight)
+		limit = min(limit, max(m.Height-10, 1))
+	}
+	m.Textarea.SetHeight(min(promptRows(m.Textarea.Value(), m.Textarea.Width()), limit))
+	// The textarea only scrolls to the cursor while handling a message, and
+	// measures against the text it last drew, so draw it first
+	m.Textarea.View()
+	m.Textarea, _ = m.Textarea.Update(nil)
+	m.Viewport.Height = m.calculateViewportHeight()
+}
+
+// promptRows counts the rows text takes in a textarea width columns wide
+func promptRows(text string, width int) int {
+	rows := 0
+	for _, line := range strings.Split(text, "\n") {
+		rows += wrappedRows([]rune(line), width)
+	}
+	return rows
+}
+
+// wrappedRows counts the rows line is wrapped onto, wrapping words as the
+// textarea does, including the row it adds when the cursor would not fit
+// after a full last row
+func wrappedRows(line []rune, width int) int {
+	if width <= 0 {
+		return 1
+	}
+	rows, rowWidth, spaces := 1, 0, 0
+	var word []rune
+	for _, r := range line {
+		if unicode.IsSpace(r) {
+			spaces++
+		} else {
+			word = append(word, r)
+		}
+		wordWidth := ansi.StringWidth(string(word))
+		if spaces > 0 {
+			if rowWidth+wordWidth+spaces > width {
+				rows++
+				rowWidth = 0
+			}
+			rowWidth += wordWidth + spaces
+			spaces = 0
+			word = nil
+		} else if wordWidth+ansi.StringWidth(string(word[len(word)-1])) > width {
+			// A word as wide as a row is broken
+			if rowWidth > 0 {
+				rows++
+			}
+			rowWidth = wordWidth
+			word = nil
+		}
+	}
+	if rowWidth+ansi.StringWidth(string(word))+spaces >= width {
+		rows++
+	}
+	return rows
+}
+
+// pastedText is text pasted into the terminal, with the line breaks the
+// textarea expects; terminals send them as carriage returns
+func pastedText(runes []rune) string {
+	text := strings.ReplaceAll(string(runes), "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// paste puts pasted text into the prompt whole, so its line breaks do not
+// send it line by line and, from read mode, its letters are not taken as
+// commands
+func (m Model) paste(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.Mode == ReadMode {
+		if m.IsWaiting || m.ChatRequested {
+			return m, nil
+		}
+		m.Mode = PromptMode
+		m.Textarea.Focus()
+		m.LastKeyWasG = false
+	}
+	m.Textarea.InsertString(pastedText(msg.Runes))
+	m.fitPrompt()
+	return m, nil
+}
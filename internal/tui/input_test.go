@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"tama/internal/config"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// typeText types text into the prompt a key at a time
+func typeText(t *testing.T, m Model, text string) Model {
+	t.Helper()
+	for _, r := range text {
+		m = pressKey(t, m, r)
+	}
+	return m
+}
+
+func TestNewlineKeysBreakTheLine(t *testing.T) {
+	// Given a prompt holding a line of text
+	m := newPromptModeModel(t)
+	m = typeText(t, m, "first")
+
+	// When the user presses alt+enter and ctrl+j between lines
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	m = updatedModel.(Model)
+	assert.Nil(t, cmd, "Nothing is sent")
+	m = typeText(t, m, "second")
+	m, _ = pressType(t, m, tea.KeyCtrlJ)
+	m = typeText(t, m, "third")
+
+	// Then the prompt holds three lines and grows to show them
+	assert.Equal(t, "first\nsecond\nthird", m.Textarea.Value())
+	assert.Equal(t, 3, m.Textarea.Height())
+	assert.Empty(t, m.MessagePairs)
+
+	// When the user presses enter
+	m, cmd = pressType(t, m, tea.KeyEnter)
+
+	// Then the three lines are sent as one request and the prompt shrinks
+	require.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "first\nsecond\nthird", m.MessagePairs[0].Request)
+	assert.Equal(t, 1, m.Textarea.Height())
+}
+
+func TestConfiguredInputKeys(t *testing.T) {
+	// Given a config where enter breaks the line and alt+enter sends
+	m := newPromptModeModel(t)
+	m.Config = config.Config{Input: config.InputConfig{
+		Submit:  []string{"alt+enter"},
+		Newline: []string{"enter"},
+	}}
+	m = typeText(t, m, "one")
+
+	// When the user presses enter between lines
+	m, cmd := pressType(t, m, tea.KeyEnter)
+	assert.Nil(t, cmd)
+	m = typeText(t, m, "two")
+	assert.Empty(t, m.MessagePairs, "Enter no longer sends")
+
+	// And then alt+enter
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	m = updatedModel.(Model)
+
+	// Then both lines are sent
+	require.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "one\ntwo", m.MessagePairs[0].Request)
+}
+
+func TestEnterBreaksTheLineWhenNotASubmitKey(t *testing.T) {
+	// Given a config sending with ctrl+s only
+	m := newPromptModeModel(t)
+	m.Config.Input.Submit = []string{"ctrl+s"}
+	m = typeText(t, m, "one")
+
+	// When the user presses enter
+	m, _ = pressType(t, m, tea.KeyEnter)
+	m = typeText(t, m, "two")
+
+	// Then the textarea breaks the line
+	assert.Equal(t, "one\ntwo", m.Textarea.Value())
+	assert.Empty(t, m.MessagePairs)
+
+	// And ctrl+s sends
+	m, _ = pressType(t, m, tea.KeyCtrlS)
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "one\ntwo", m.MessagePairs[0].Request)
+}
+
+func TestPasteKeepsLineBreaks(t *testing.T) {
+	// Given an empty prompt
+	m := newPromptModeModel(t)
+
+	// When a multi-line snippet is pasted, its line breaks sent as carriage
+	// returns as terminals do
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("func main() {\r\tfmt.Println(1)\r\n}"), Paste: true})
+	m = updatedModel.(Model)
+
+	// Then it is inserted whole rather than sent line by line
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.MessagePairs)
+	assert.Equal(t, "func main() {\n    fmt.Println(1)\n}", m.Textarea.Value())
+	assert.Equal(t, 3, m.Textarea.Height())
+}
+
+func TestPasteInReadModeOpensThePrompt(t *testing.T) {
+	// Given a conversation in read mode
+	m := newSessionModel(t, threePairs...)
+	m.CurrentPairIndex = 1
+
+	// When text is pasted, including letters that are read mode keys
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("dJK"), Paste: true})
+	m = updatedModel.(Model)
+
+	// Then it goes into the prompt instead of running the keys
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "dJK", m.Textarea.Value())
+	assert.Equal(t, 1, m.CurrentPairIndex)
+	assert.Len(t, m.MessagePairs, 3)
+}
+
+func TestPromptScrollsPastMaxHeight(t *testing.T) {
+	// Given a prompt limited to 4 rows
+	m := newPromptModeModel(t)
+	m.Config.Input.MaxHeight = 4
+	viewportHeight := m.Viewport.Height
+
+	// When the user pastes 20 lines
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(strings.Join(lines, "\n")), Paste: true})
+	m = updatedModel.(Model)
+
+	// Then the prompt stops growing at 4 rows, showing the cursor's line
+	assert.Equal(t, 4, m.Textarea.Height())
+	assert.Equal(t, viewportHeight-3, m.Viewport.Height)
+	view := m.Textarea.View()
+	assert.Contains(t, view, "line 20")
+	assert.NotContains(t, view, "line 16")
+
+	// When the user moves to the top of the text
+	for range 19 {
+		m, _ = pressType(t, m, tea.KeyUp)
+	}
+
+	// Then it scrolls with the cursor
+	view = m.Textarea.View()
+	assert.Contains(t, view, "line 1 ")
+	assert.NotContains(t, view, "line 20")
+}
+
+func TestCursorMovesWithinMultilineInput(t *testing.T) {
+	// Given a prompt with two lines and the cursor at the end
+	m := newPromptModeModel(t)
+	m.setPrompt("ab\ncd")
+
+	// When the user moves up and to the start of the line and types
+	m, _ = pressType(t, m, tea.KeyUp)
+	m, _ = pressType(t, m, tea.KeyHome)
+	m = typeText(t, m, "x")
+	m, _ = pressType(t, m, tea.KeyDown)
+	m = typeText(t, m, "y")
+
+	// Then the text goes where the cursor is
+	assert.Equal(t, "xab\ncyd", m.Textarea.Value())
+}
+
+func TestPromptRowsMatchTextarea(t *testing.T) {
+	// Given a narrow textarea
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.SetWidth(12)
+
+	for _, line := range []string{
+		"",
+		"short",
+		"exactly ten",
+		"the quick brown fox jumps over the lazy dog",
+		"averyveryverylongwordthatcannotwrap",
+		"wide 漢字漢字漢字漢字 text",
+		"ends with spaces    ",
+	} {
+		// When it holds the line with the cursor at its end
+		ta.SetValue(line)
+
+		// Then the rows counted match the rows it wraps the line onto
+		assert.Equal(t, ta.LineInfo().Height, wrappedRows([]rune(line), ta.Width()), line)
+	}
+	assert.Equal(t, 4, promptRows("a\nb\nthe quick brown fox", ta.Width()))
+}
@@ -0,0 +1,21 @@
+package tui
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain points the XDG directories at a scratch location so tests never
+// touch the user's saved model, sessions or config
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "tama-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_DATA_HOME", dir)
+	os.Setenv("XDG_CONFIG_HOME", dir)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -6,6 +6,11 @@ import (
 	"strings"
 	"time"
 
+	"tama/internal/config"
+	"tama/internal/history"
+	"tama/internal/ollama"
+	"tama/internal/session"
+
 	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/viewport"
@@ -14,51 +19,15 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Ollama API types
-type OllamaMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type ChatRequest struct {
-	Model    string          `json:"model"`
-	Messages []OllamaMessage `json:"messages"`
-	Stream   bool            `json:"stream"`
-}
-
-type ChatResponse struct {
-	Model     string        `json:"model"`
-	CreatedAt string        `json:"created_at"`
-	Message   OllamaMessage `json:"message"`
-	Done      bool          `json:"done"`
-}
-
-type StreamResponse struct {
-	Model     string        `json:"model"`
-	CreatedAt string        `json:"created_at"`
-	Message   OllamaMessage `json:"message"`
-	Done      bool          `json:"done"`
-}
-
 // Application types
-type MessagePair struct {
-	Request   string
-	Response  string
-	Duration  time.Duration // Time taken to generate the response
-	Cancelled bool          // Whether the request was cancelled
-}
 
-type ModelsResponse struct {
-	Models []struct {
-		Name string `json:"name"`
-	} `json:"models"`
-}
+// MessagePair is a request and its response; pairs are persisted as part of
+// a session
+type MessagePair = session.Pair
 
 const (
-	ollamaChatURL   = "http://localhost:11434/api/chat"
-	ollamaModelsURL = "http://localhost:11434/api/ps"
-	defaultModel    = "gpt-oss:20b"
-	ContentWidth    = 100
+	defaultModel = "gpt-oss:20b"
+	ContentWidth = 100
 )
 
 // Mode represents the current interaction mode
@@ -67,12 +36,42 @@ type Mode int
 const (
 	PromptMode Mode = iota
 	ReadMode
+	ModelPickerMode
+	SessionPickerMode
+	CommandPickerMode
+	SearchMode
+	SessionSearchMode
+	CodeBlockMode
+	HistoryPickerMode
+	ShellConfirmMode
 )
 
 // Bubbletea messages
 type tickMsg time.Time
-type ResponseLineMsg string
-type ResponseCompleteMsg string
+
+// Messages streamed for a request carry its ID, so that those arriving after
+// it was cancelled or replaced by another request are dropped
+type ResponseLineMsg struct {
+	RequestID int
+	Text      string // Response streamed so far
+}
+type ThinkingLineMsg struct {
+	RequestID int
+	Thinking  string // Reasoning streamed so far
+}
+type ResponseCompleteMsg struct {
+	RequestID int
+	Response  string
+}
+type ResponseMetricsMsg struct {
+	RequestID int
+	Metrics   ollama.Metrics // Statistics from the final chunk, sent before ResponseCompleteMsg
+}
+type responseFailedMsg struct {
+	requestID int
+	response  string // Content streamed before the failure
+	err       error
+}
 type errorMsg struct{ err error }
 type modelLoadedMsg struct{ model string }
 type modelSelectedMsg struct{ model string }
@@ -82,6 +81,7 @@ type SetSendFuncMsg struct{ Send func(tea.Msg) }
 // Model holds the application state
 type Model struct {
 	Mode                   Mode
+	PreviousMode           Mode // Mode to return to when an overlay closes
 	Textarea               textarea.Model
 	Viewport               viewport.Model
 	MessagePairs           []MessagePair
@@ -101,11 +101,43 @@ type Model struct {
 	LoadingModel           bool
 	ResponseLines          []string
 	StreamBuffer           string
-	LastKeyWasG            bool          // Track if last key pressed was 'g' for 'gg' sequence
-	Send                   func(tea.Msg) // Function to send messages to the program
-	cancelCurrentRequestFn func()        // Function to cancel the current request
-	ChatURL                string        // Ollama chat API URL (configurable for testing)
-	ResponseTargetIndex    int           // Index of message pair currently receiving response
+	LastKeyWasG            bool              // Track if last key pressed was 'g' for 'gg' sequence
+	PendingYank            bool              // Whether 'y' was pressed and the key saying what to copy is awaited
+	PendingCtrlX           bool              // Whether Ctrl+X was pressed in prompt mode, starting Ctrl+X Ctrl+E
+	Send                   func(tea.Msg)     // Function to send messages to the program
+	cancelCurrentRequestFn func()            // Function to cancel the current request
+	RequestID              int               // ID of the request whose stream is awaited; changed on cancel
+	cancelCompactFn        func()            // Stops the compaction in progress
+	Client                 *ollama.Client    // Ollama API client (configurable for testing)
+	ResponseTargetIndex    int               // Index of message pair currently receiving response
+	Picker                 picker            // Overlay list shown in the picker modes
+	Blocks                 blockPicker       // Code blocks of the focused pair, shown in CodeBlockMode
+	Shell                  shellRun          // Request whose ! commands are confirmed and run in ShellConfirmMode
+	History                *history.History  // Prompts sent, recalled with Up/Down and Ctrl+R (nil disables the history)
+	Recall                 recall            // Place in the history while Up/Down cycle through it
+	HistoryGlobal          bool              // Whether the history picker lists the prompts of every session
+	Session                *session.Session  // Conversation being auto-saved
+	Sessions               *session.Store    // Where sessions are saved (nil disables saving)
+	InstalledModels        []string          // Names from /api/tags, for completion
+	ContextLength          int               // num_ctx set by the current model, from /api/show (0 for the default)
+	Compacting             bool              // Whether a summary for /compact is being generated
+	Editing                bool              // Whether the prompt holds an edited request, which starts a branch when sent
+	OversizeConfirmed      string            // Request warned about as too big for the context with its attachments; sending it again goes ahead
+	OversizeCommands       []session.Command // Output of the commands of OversizeConfirmed, sent with it instead of running them again
+	EditIndex              int               // Index of the pair being edited
+	Regenerating           bool              // Whether the pending response replaces the focused one via 'r'
+	RegeneratedFrom        int               // Response shown before regenerating, restored if it is abandoned
+	Config                 config.Config     // Settings from the config file
+	SystemPrompt           string            // Session system prompt; overrides the config default
+	SystemPromptExpanded   bool              // Whether the system prompt header shows the full text
+	ThinkingExpanded       bool              // Whether thinking sections show the full reasoning
+	CommandOutputExpanded  bool              // Whether the output of a request's ! commands is shown in full
+	Search                 search            // Pattern searched for with / or ?, and its current match
+	Options                ollama.Options    // Session generation options; override the config defaults
+	Think                  ollama.Think      // Session reasoning setting; overrides the config default
+	Notice                 string            // Short message shown in the status line
+	NoticeIsError          bool
+	markdown               *markdownCache // Rendered responses, shared by copies of the model
 }
 
 func InitialModel() Model {
@@ -115,6 +147,7 @@ func InitialModel() Model {
 	ta.CharLimit = 0
 	ta.SetWidth(ContentWidth - 4)
 	ta.SetHeight(1)
+	ta.MaxHeight = 0 // fitPrompt caps the rows shown, not the lines typed
 	ta.ShowLineNumbers = false
 	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
 	ta.Cursor.SetMode(cursor.CursorStatic)
@@ -127,6 +160,9 @@ func InitialModel() Model {
 		glamour.WithWordWrap(ContentWidth),
 	)
 
+	// An unreadable history starts empty
+	h, _ := history.Load(history.DefaultPath())
+
 	return Model{
 		Mode:             PromptMode,
 		Textarea:         ta,
@@ -135,27 +171,62 @@ func InitialModel() Model {
 		CurrentPairIndex: 0,
 		CurrentModel:     loadLastUsedModel(),
 		Renderer:         r,
-		ChatURL:          ollamaChatURL,
+		markdown:         &markdownCache{},
+		Client:           ollama.NewClient(ollama.DefaultBaseURL),
+		Session:          session.New(),
+		Sessions:         session.NewStore(session.DefaultDir()),
+		History:          h,
 	}
 }
 
-// Helper functions
-func HasParagraphBoundary(text string) bool {
-	return strings.Contains(text, "\n\n")
+// setNotice shows a message in the status line until the next key press
+func (m *Model) setNotice(text string, isError bool) {
+	m.Notice = text
+	m.NoticeIsError = isError
+}
+
+// systemPrompt returns the system prompt sent with every request: the one
+// set for this session, else the configured default for the current model
+func (m Model) systemPrompt() string {
+	if m.SystemPrompt != "" {
+		return m.SystemPrompt
+	}
+	return m.Config.SystemPromptFor(m.CurrentModel)
+}
+
+// options returns the generation options sent with every request: the
+// configured defaults overridden by any set for this session
+func (m Model) options() ollama.Options {
+	return m.Config.Options.Merge(m.Options)
 }
 
-// XDG_DATA_HOME functions
-func getDataHome() string {
-	dataHome := os.Getenv("XDG_DATA_HOME")
-	if dataHome == "" {
-		home, _ := os.UserHomeDir()
-		dataHome = filepath.Join(home, ".local", "share")
+// think returns the reasoning setting sent with every request: the one set
+// for this session, else the configured default
+func (m Model) think() ollama.Think {
+	if m.Think != "" {
+		return m.Think
 	}
-	return filepath.Join(dataHome, "tama")
+	return m.Config.Think
+}
+
+// optionsSummary lists the generation options and reasoning setting in
+// effect, for the status line
+func (m Model) optionsSummary() string {
+	summary := m.options().String()
+	if think := m.think(); think != "" {
+		summary = strings.TrimSpace(summary + " think=" + string(think))
+	}
+	return summary
+}
+
+// Helper functions
+func HasParagraphBoundary(text string) bool {
+	return strings.Contains(text, "\n\n")
 }
 
+// Last used model persistence
 func saveLastUsedModel(modelName string) error {
-	dataDir := getDataHome()
+	dataDir := config.DataDir()
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return err
 	}
@@ -165,7 +236,7 @@ func saveLastUsedModel(modelName string) error {
 }
 
 func loadLastUsedModel() string {
-	filePath := filepath.Join(getDataHome(), "last-model")
+	filePath := filepath.Join(config.DataDir(), "last-model")
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return defaultModel
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type modelListMsg struct {
+	local   []ollama.LocalModel
+	running []ollama.RunningModel
+	err     error // Why the models could not be listed
+}
+
+func listModelsCmd(client *ollama.Client) tea.Cmd {
+	return func() tea.Msg {
+		local, err := client.ListLocal(context.Background())
+		if err != nil {
+			return modelListMsg{err: err}
+		}
+		running, err := client.ListRunning(context.Background())
+		if err != nil {
+			return modelListMsg{err: err}
+		}
+		return modelListMsg{local: local, running: running}
+	}
+}
+
+// openModelPicker shows the model picker and starts fetching installed models
+func (m *Model) openModelPicker() tea.Cmd {
+	m.openPicker(ModelPickerMode, "Models")
+	return listModelsCmd(m.Client)
+}
+
+// selectModel switches the model used for subsequent requests
+func (m *Model) selectModel(name string) tea.Cmd {
+	if name == m.CurrentModel {
+		return nil
+	}
+	m.CurrentModel = name
+	m.ModelIsLoaded = false
+	saveLastUsedModel(m.CurrentModel)
+	return tea.Batch(checkModelStatus(m.Client, m.CurrentModel), contextSizeCmd(m.Client, m.CurrentModel))
+}
+
+// modelPickerItems lists installed models with their size, family and
+// quantization, marking the ones currently loaded into memory
+func modelPickerItems(local []ollama.LocalModel, running []ollama.RunningModel, current string) []pickerItem {
+	loaded := make(map[string]bool, len(running))
+	for _, r := range running {
+		loaded[r.Name] = true
+	}
+
+	nameWidth := 0
+	for _, model := range local {
+		nameWidth = max(nameWidth, len(model.Name))
+	}
+
+	items := make([]pickerItem, 0, len(local))
+	for _, model := range local {
+		marker := "  "
+		if loaded[model.Name] {
+			marker = "● "
+		}
+		details := []string{formatSize(model.Size)}
+		if model.Details.Family != "" {
+			details = append(details, model.Details.Family)
+		}
+		if model.Details.ParameterSize != "" {
+			details = append(details, model.Details.ParameterSize)
+		}
+		if model.Details.QuantizationLevel != "" {
+			details = append(details, model.Details.QuantizationLevel)
+		}
+		if loaded[model.Name] {
+			details = append(details, "loaded")
+		}
+		if model.Name == current {
+			details = append(details, "current")
+		}
+		items = append(items, pickerItem{
+			Title:  marker + fmt.Sprintf("%-*s", nameWidth, model.Name),
+			Detail: strings.Join(details, " • "),
+			Value:  model.Name,
+		})
+	}
+	return items
+}
+
+// formatSize renders a byte count using decimal units, as ollama list does
+func formatSize(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
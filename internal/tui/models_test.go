@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLocalModels = []ollama.LocalModel{
+	{Name: "gpt-oss:20b", Size: 13780000000, Details: ollama.ModelDetails{Family: "gptoss", ParameterSize: "20.9B", QuantizationLevel: "MXFP4"}},
+	{Name: "llama3.2:3b", Size: 2019393189, Details: ollama.ModelDetails{Family: "llama", ParameterSize: "3.2B", QuantizationLevel: "Q4_K_M"}},
+	{Name: "qwen3:8b", Size: 5225000000, Details: ollama.ModelDetails{Family: "qwen3", ParameterSize: "8.2B", QuantizationLevel: "Q4_K_M"}},
+}
+
+func newReadModeModel(t *testing.T) Model {
+	t.Helper()
+	m := InitialModel()
+	updatedModel, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	return updatedModel.(Model)
+}
+
+func TestPressMToOpenModelPicker(t *testing.T) {
+	// Given a running tama in read mode
+	m := newReadModeModel(t)
+	m.CurrentModel = "gpt-oss:20b"
+
+	// When the user presses "m"
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = updatedModel.(Model)
+
+	// Then the model picker opens and starts loading the installed models
+	assert.Equal(t, ModelPickerMode, m.Mode, "Should switch to model picker mode")
+	assert.NotNil(t, cmd, "Should fetch installed models")
+	assert.Contains(t, m.View(), "Loading", "Picker should show loading state")
+
+	// When the installed models arrive
+	updatedModel, _ = m.Update(modelListMsg{
+		local:   testLocalModels,
+		running: []ollama.RunningModel{{Name: "gpt-oss:20b"}},
+	})
+	m = updatedModel.(Model)
+
+	// Then each model is listed with size, family, quantization and loaded marker
+	view := m.View()
+	assert.Contains(t, view, "llama3.2:3b")
+	assert.Contains(t, view, "2.0 GB")
+	assert.Contains(t, view, "llama")
+	assert.Contains(t, view, "Q4_K_M")
+	assert.Contains(t, view, "● gpt-oss:20b", "Loaded model should be marked")
+}
+
+func TestModelPickerFilterAndSelect(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	// Given the model picker is open with installed models
+	m := newReadModeModel(t)
+	m.CurrentModel = "gpt-oss:20b"
+	m.MessagePairs = []MessagePair{{Request: "First", Response: "Answer", Model: "gpt-oss:20b"}}
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(modelListMsg{local: testLocalModels})
+	m = updatedModel.(Model)
+
+	// When the user types a filter
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("qwen")})
+	m = updatedModel.(Model)
+
+	// Then only matching models are listed
+	view := m.View()
+	assert.Contains(t, view, "qwen3:8b")
+	assert.NotContains(t, view, "llama3.2:3b")
+
+	// When the user presses enter
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then the current model switches and the picker closes
+	assert.Equal(t, "qwen3:8b", m.CurrentModel)
+	assert.Equal(t, ReadMode, m.Mode, "Should return to read mode")
+	assert.NotNil(t, cmd, "Should check whether the new model is loaded")
+	assert.Equal(t, "qwen3:8b", loadLastUsedModel(), "Selected model should be remembered")
+
+	// And earlier pairs keep the model that produced them
+	assert.Equal(t, "gpt-oss:20b", m.MessagePairs[0].Model)
+
+	// When the user sends a new request
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	m.Textarea.SetValue("Second")
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then the new pair records the new model
+	assert.Equal(t, "qwen3:8b", m.MessagePairs[1].Model)
+}
+
+func TestModelPickerEscKeepsCurrentModel(t *testing.T) {
+	// Given the model picker is open
+	m := newReadModeModel(t)
+	m.CurrentModel = "gpt-oss:20b"
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(modelListMsg{local: testLocalModels})
+	m = updatedModel.(Model)
+
+	// When the user presses down then esc
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updatedModel.(Model)
+
+	// Then the picker closes without changing the model
+	assert.Equal(t, ReadMode, m.Mode)
+	assert.Equal(t, "gpt-oss:20b", m.CurrentModel)
+}
+
+func TestModelPickerClosesOnlyOnItsOwnError(t *testing.T) {
+	// Given the model picker is open
+	m := newReadModeModel(t)
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = updatedModel.(Model)
+
+	// When a background status check fails
+	updatedModel, _ = m.Update(errorMsg{err: errors.New("connection refused")})
+	m = updatedModel.(Model)
+
+	// Then the picker stays open
+	assert.Equal(t, ModelPickerMode, m.Mode)
+
+	// When listing the models fails
+	updatedModel, _ = m.Update(modelListMsg{err: errors.New("connection refused")})
+	m = updatedModel.(Model)
+
+	// Then the picker closes with the error
+	assert.Equal(t, ReadMode, m.Mode)
+	assert.EqualError(t, m.Err, "connection refused")
+}
+
+func TestModelNameShownInResponseBorder(t *testing.T) {
+	m := newReadModeModel(t)
+	m.MessagePairs = []MessagePair{{Request: "Hi", Response: "Hello", Model: "llama3.2:3b"}}
+	m.updateViewport()
+
+	assert.Contains(t, m.Viewport.View(), "llama3.2:3b", "Response border should name the model")
+}
+
+func TestListModelsCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"llama3.2:3b"},{"name":"qwen3:8b"}]}`)
+		case "/api/ps":
+			fmt.Fprint(w, `{"models":[{"name":"qwen3:8b"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	msg := listModelsCmd(ollama.NewClient(server.URL))()
+
+	list, ok := msg.(modelListMsg)
+	require.True(t, ok, "Should return modelListMsg")
+	assert.Len(t, list.local, 2)
+	assert.Len(t, list.running, 1)
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "2.0 GB", formatSize(2019393189))
+	assert.Equal(t, "13.8 GB", formatSize(13780000000))
+}
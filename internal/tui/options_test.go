@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"testing"
+
+	"tama/internal/config"
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCommandOverridesConfigOptions(t *testing.T) {
+	// Given a running tama with a configured default temperature
+	m := newSessionModel(t)
+	var defaults ollama.Options
+	require.NoError(t, defaults.Set("temperature", "0.8"))
+	m.Config = config.Config{Options: defaults}
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+
+	// When the user sets options for the session
+	m, _ = submit(t, m, "/set temperature 0.2")
+	m, _ = submit(t, m, "/set seed 42")
+
+	// Then they are sent with the request and shown in the status line
+	req := m.newChatRequest([]MessagePair{{Request: "Hi"}})
+	require.NotNil(t, req.Options)
+	assert.Equal(t, 0.2, *req.Options.Temperature)
+	assert.Equal(t, 42, *req.Options.Seed)
+	assert.Contains(t, m.View(), "temperature=0.2 seed=42")
+
+	// And each request records the options it was sent with
+	m, _ = submit(t, m, "Hello")
+	assert.Equal(t, "temperature=0.2 seed=42", m.MessagePairs[0].Options.String())
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 42, *saved.Options.Seed)
+	assert.Equal(t, 0.2, *saved.Pairs[0].Options.Temperature)
+
+	// When the user resets the temperature
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Hi"})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	m, _ = submit(t, m, "/set temperature")
+
+	// Then the configured default applies again
+	assert.Equal(t, "temperature=0.8 seed=42", m.options().String())
+}
+
+func TestSetCommandRejectsUnknownOption(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	m, _ = submit(t, m, "/set warmth 1")
+
+	assert.True(t, m.NoticeIsError)
+	assert.Contains(t, m.Notice, `unknown option "warmth"`)
+	assert.Equal(t, "/set warmth 1", m.Textarea.Value())
+}
+
+func TestNoOptionsSentByDefault(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	req := m.newChatRequest([]MessagePair{{Request: "Hi"}})
+
+	assert.Nil(t, req.Options, "Models keep their own defaults")
+	assert.NotContains(t, m.View(), "temperature")
+}
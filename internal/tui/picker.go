@@ -0,0 +1,222 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// pickerItem is one selectable row of a picker overlay
+type pickerItem struct {
+	Title  string // Main text, matched against the filter
+	Detail string // Secondary text shown dimmed after the title
+	Value  string // Value returned when the item is chosen
+}
+
+// picker is a filterable list shown in place of the viewport
+type picker struct {
+	Title    string
+	Filter   textinput.Model
+	Items    []pickerItem
+	Loading  bool
+	filtered []int // Indexes into Items that match the filter
+	cursor   int   // Position within filtered
+}
+
+func newPicker(title string) picker {
+	ti := textinput.New()
+	ti.Prompt = "/ "
+	ti.Placeholder = "filter"
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.Focus()
+	return picker{
+		Title:   title,
+		Filter:  ti,
+		Loading: true,
+	}
+}
+
+func (p *picker) SetItems(items []pickerItem) {
+	p.Items = items
+	p.Loading = false
+	p.applyFilter()
+}
+
+// applyFilter keeps the items whose title or detail contains every word of
+// the filter, ignoring case
+func (p *picker) applyFilter() {
+	words := strings.Fields(strings.ToLower(p.Filter.Value()))
+	p.filtered = p.filtered[:0]
+	for i, item := range p.Items {
+		haystack := strings.ToLower(item.Title + " " + item.Detail)
+		matched := true
+		for _, w := range words {
+			if !strings.Contains(haystack, w) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			p.filtered = append(p.filtered, i)
+		}
+	}
+	p.cursor = min(p.cursor, max(len(p.filtered)-1, 0))
+}
+
+// Selected returns the item under the cursor
+func (p picker) Selected() (pickerItem, bool) {
+	if len(p.filtered) == 0 {
+		return pickerItem{}, false
+	}
+	return p.Items[p.filtered[p.cursor]], true
+}
+
+// Update moves the cursor or edits the filter
+func (p picker) Update(msg tea.KeyMsg) (picker, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyUp, tea.KeyCtrlP:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		return p, nil
+	case tea.KeyDown, tea.KeyCtrlN:
+		if p.cursor < len(p.filtered)-1 {
+			p.cursor++
+		}
+		return p, nil
+	}
+
+	var cmd tea.Cmd
+	before := p.Filter.Value()
+	p.Filter, cmd = p.Filter.Update(msg)
+	if p.Filter.Value() != before {
+		p.cursor = 0
+		p.applyFilter()
+	}
+	return p, cmd
+}
+
+func (p picker) View(width, height int) string {
+	var b strings.Builder
+
+	titleText := "──── " + p.Title + " "
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Render(titleText + strings.Repeat("─", max(width-lipgloss.Width(titleText), 0)))
+	b.WriteString(title)
+	b.WriteString("\n")
+	b.WriteString(p.Filter.View())
+	b.WriteString("\n\n")
+
+	listHeight := max(height-3, 1)
+	switch {
+	case p.Loading:
+		b.WriteString("Loading...")
+	case len(p.filtered) == 0:
+		b.WriteString("No matches")
+	default:
+		// Scroll the list so the cursor stays visible
+		start := max(p.cursor-listHeight+1, 0)
+		end := min(start+listHeight, len(p.filtered))
+		selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+		detailStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+		for i := start; i < end; i++ {
+			item := p.Items[p.filtered[i]]
+			line := "  " + item.Title
+			if i == p.cursor {
+				line = selectedStyle.Render("› " + item.Title)
+			}
+			if item.Detail != "" {
+				line += "  " + detailStyle.Render(item.Detail)
+			}
+			b.WriteString(ansi.Truncate(line, width, "…"))
+			if i < end-1 {
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	return lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(b.String())
+}
+
+// isPickerMode reports whether a picker overlay is shown
+func (m Model) isPickerMode() bool {
+	return m.Mode == ModelPickerMode || m.Mode == SessionPickerMode || m.Mode == CommandPickerMode ||
+		m.Mode == SessionSearchMode || m.Mode == HistoryPickerMode
+}
+
+// openPicker shows an empty, loading picker in place of the viewport
+func (m *Model) openPicker(mode Mode, title string) {
+	m.PreviousMode = m.Mode
+	m.Mode = mode
+	m.Picker = newPicker(title)
+	m.Textarea.Blur()
+	m.Viewport.Height = m.calculateViewportHeight()
+}
+
+func (m *Model) closePicker() {
+	m.Mode = m.PreviousMode
+	if m.Mode == PromptMode {
+		m.Textarea.Focus()
+	}
+	m.Viewport.Height = m.calculateViewportHeight()
+}
+
+// pickerFailed reports why the items of the picker in mode could not be
+// loaded, closing it if it is still open
+func (m *Model) pickerFailed(mode Mode, err error) {
+	m.Err = err
+	if m.Mode == mode {
+		m.closePicker()
+	}
+}
+
+func (m Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.closePicker()
+		return m, nil
+	case tea.KeyEnter:
+		mode := m.Mode
+		item, ok := m.Picker.Selected()
+		m.closePicker()
+		if !ok {
+			return m, nil
+		}
+		switch mode {
+		case ModelPickerMode:
+			return m, m.selectModel(item.Value)
+		case SessionPickerMode:
+			return m, m.openSession(item.Value)
+		case SessionSearchMode:
+			return m, m.openMatch(parseMatchValue(item.Value))
+		case CommandPickerMode:
+			m.startCommand(item.Value)
+		case HistoryPickerMode:
+			m.setPrompt(item.Value)
+		}
+		return m, nil
+	case tea.KeyCtrlR:
+		if m.Mode == HistoryPickerMode {
+			// Again for the next older match, as in a shell
+			m.Picker, _ = m.Picker.Update(tea.KeyMsg{Type: tea.KeyDown})
+			return m, nil
+		}
+	case tea.KeyTab:
+		if m.Mode == HistoryPickerMode {
+			// Switch between every session's prompts and this one's
+			query := m.Picker.Filter.Value()
+			m.closePicker()
+			m.openHistoryPicker(query, !m.HistoryGlobal)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Picker, cmd = m.Picker.Update(msg)
+	return m, cmd
+}
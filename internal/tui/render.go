@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"strings"
+
+	"tama/internal/session"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// markdownCache renders markdown with glamour, remembering the output for
+// finished responses and for the completed blocks of the response being
+// streamed, so each streamed chunk only re-renders the block still open.
+// Everything is forgotten when the renderer changes, e.g. on resize.
+type markdownCache struct {
+	renderer *glamour.TermRenderer // Renderer the cached output came from
+	done     map[string]string     // Rendered finished text
+	stream   streamState
+}
+
+// streamState holds the completed blocks of the response being streamed
+type streamState struct {
+	source   string   // Text of the completed blocks
+	rendered []string // Each block rendered, without surrounding blank lines
+}
+
+func (c *markdownCache) use(r *glamour.TermRenderer) {
+	if c.renderer != r {
+		*c = markdownCache{renderer: r, done: map[string]string{}}
+	}
+}
+
+// render renders finished text
+func (c *markdownCache) render(r *glamour.TermRenderer, text string) (string, error) {
+	c.use(r)
+	if rendered, ok := c.done[text]; ok {
+		return rendered, nil
+	}
+	rendered, err := r.Render(text)
+	if err != nil {
+		return "", err
+	}
+	c.done[text] = rendered
+	return rendered, nil
+}
+
+// renderStreaming renders text that is still growing. Blocks completed
+// since the last call are rendered once and kept; only the trailing open
+// block is rendered every time.
+func (c *markdownCache) renderStreaming(r *glamour.TermRenderer, text string) (string, error) {
+	c.use(r)
+	if !strings.HasPrefix(text, c.stream.source) {
+		// A different response
+		c.stream = streamState{}
+	}
+
+	blocks, open := splitBlocks(text[len(c.stream.source):])
+	for _, block := range blocks {
+		rendered, err := r.Render(block)
+		if err != nil {
+			return "", err
+		}
+		c.stream.source += block
+		c.stream.rendered = append(c.stream.rendered, trimRendered(rendered))
+	}
+
+	parts := c.stream.rendered
+	if strings.TrimSpace(open) != "" {
+		rendered, err := r.Render(open)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts[:len(parts):len(parts)], trimRendered(rendered))
+	}
+	// Blocks are rejoined with the margins glamour puts around a document
+	return "\n" + strings.Join(parts, "\n\n") + "\n\n", nil
+}
+
+// trimRendered strips the blank lines glamour adds around a document
+func trimRendered(rendered string) string {
+	return strings.TrimRight(strings.TrimLeft(rendered, "\n"), "\n")
+}
+
+// splitBlocks splits text into the blocks completed so far, each ended by a
+// paragraph boundary (see HasParagraphBoundary), and the rest, which may
+// still grow. Blank lines inside fenced code blocks are not boundaries, and
+// a line is only considered once its newline has arrived.
+func splitBlocks(text string) (blocks []string, rest string) {
+	var f session.Fences
+	start, pos := 0, 0
+	for {
+		end := strings.IndexByte(text[pos:], '\n')
+		if end < 0 {
+			break
+		}
+		line := text[pos : pos+end]
+		next := pos + end + 1
+		if f.Scan(line) == session.Text && strings.TrimSpace(line) == "" && strings.TrimSpace(text[start:pos]) != "" {
+			blocks = append(blocks, text[start:next])
+			start = next
+		}
+		pos = next
+	}
+	return blocks, text[start:]
+}
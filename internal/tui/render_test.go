@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		blocks []string
+		rest   string
+	}{
+		{"No boundary yet", "Once upon", nil, "Once upon"},
+		{"Completed paragraph", "One.\n\nTwo", []string{"One.\n\n"}, "Two"},
+		{"Boundary not yet arrived", "One.\n", nil, "One.\n"},
+		{"Blank lines inside a fence", "```go\nx := 1\n\ny := 2\n```\n\nDone", []string{"```go\nx := 1\n\ny := 2\n```\n\n"}, "Done"},
+		{"Open fence", "Code:\n\n```\na\n\nb", []string{"Code:\n\n"}, "```\na\n\nb"},
+		{"Longer fences need longer closers", "````\n```\n\n````\n\nEnd", []string{"````\n```\n\n````\n\n"}, "End"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, rest := splitBlocks(tt.text)
+			assert.Equal(t, tt.blocks, blocks)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func TestRenderStreamingKeepsCompletedBlocks(t *testing.T) {
+	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle("tokyo-night"), glamour.WithWordWrap(80))
+	require.NoError(t, err)
+	c := &markdownCache{}
+
+	// When a response streams in
+	out, err := c.renderStreaming(r, "First paragraph.\n\nSecond")
+	require.NoError(t, err)
+	assert.Contains(t, ansi.Strip(out), "First paragraph.")
+	assert.Contains(t, ansi.Strip(out), "Second")
+
+	// Then completed blocks are rendered once and kept
+	assert.Equal(t, "First paragraph.\n\n", c.stream.source)
+	require.Len(t, c.stream.rendered, 1)
+	firstBlock := c.stream.rendered[0]
+
+	out, err = c.renderStreaming(r, "First paragraph.\n\nSecond paragraph.\n\n```go\nx := 1\n\n")
+	require.NoError(t, err)
+	require.Len(t, c.stream.rendered, 2, "The open code block is not complete yet")
+	assert.Equal(t, firstBlock, c.stream.rendered[0])
+	assert.Contains(t, ansi.Strip(out), "x := 1")
+
+	// And a new response starts afresh
+	_, err = c.renderStreaming(r, "Another answer")
+	require.NoError(t, err)
+	assert.Empty(t, c.stream.source)
+}
+
+func TestRenderCacheForgetsOnNewRenderer(t *testing.T) {
+	narrow, _ := glamour.NewTermRenderer(glamour.WithStandardStyle("tokyo-night"), glamour.WithWordWrap(20))
+	wide, _ := glamour.NewTermRenderer(glamour.WithStandardStyle("tokyo-night"), glamour.WithWordWrap(80))
+	c := &markdownCache{}
+	text := strings.Repeat("word ", 10)
+
+	first, err := c.render(narrow, text)
+	require.NoError(t, err)
+	again, _ := c.render(narrow, text)
+	assert.Equal(t, first, again)
+	assert.Len(t, c.done, 1)
+
+	wider, err := c.render(wide, text)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, wider, "Output is re-rendered for the new width")
+	assert.Len(t, c.done, 1)
+}
@@ -0,0 +1,226 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Searching works like less or vim: / and ? search forward and backward
+// through the conversation as rendered, and n and N repeat the search,
+// moving to other pairs when the next match is outside the focused one.
+
+// search is a pattern typed after / or ?, and where it last matched
+type search struct {
+	Input    textinput.Model // Pattern being typed in SearchMode
+	Pattern  string          // Pattern matches are highlighted for; empty when none
+	Backward bool            // Whether the search was started with ?
+	At       searchMatch     // Current match, or where the search starts from
+	Found    bool            // Whether At is a match
+	Index    int             // Position of the current match among all of them
+	Total    int             // Number of matches in the conversation
+}
+
+// searchMatch is a match in the rendered text of a pair
+type searchMatch struct {
+	Pair, Line int
+	Start, End int // Cell columns
+}
+
+// before reports whether a comes before b in the conversation
+func (a searchMatch) before(b searchMatch) bool {
+	if a.Pair != b.Pair {
+		return a.Pair < b.Pair
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Start < b.Start
+}
+
+// searchPattern matches pattern literally, ignoring case unless it has
+// upper case letters
+func searchPattern(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(pattern)
+	if !strings.ContainsFunc(pattern, unicode.IsUpper) {
+		expr = "(?i)" + expr
+	}
+	return regexp.MustCompile(expr)
+}
+
+// lineMatches finds re in a rendered line, ignoring its styling
+func lineMatches(line string, re *regexp.Regexp) [][2]int {
+	plain := ansi.Strip(line)
+	var cols [][2]int
+	for _, loc := range re.FindAllStringIndex(plain, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		start := ansi.StringWidth(plain[:loc[0]])
+		cols = append(cols, [2]int{start, start + ansi.StringWidth(plain[loc[0]:loc[1]])})
+	}
+	return cols
+}
+
+// searchMatches finds the search pattern in every pair, in order
+func (m *Model) searchMatches() []searchMatch {
+	re := searchPattern(m.Search.Pattern)
+	var matches []searchMatch
+	for i := range m.MessagePairs {
+		for line, text := range strings.Split(m.renderPair(i), "\n") {
+			for _, cols := range lineMatches(text, re) {
+				matches = append(matches, searchMatch{Pair: i, Line: line, Start: cols[0], End: cols[1]})
+			}
+		}
+	}
+	return matches
+}
+
+// openSearch shows the search prompt; backward searches with ?
+func (m *Model) openSearch(backward bool) {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	if backward {
+		ti.Prompt = "?"
+	}
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.Focus()
+	m.Search.Input = ti
+	m.Mode = SearchMode
+	m.Viewport.Height = m.calculateViewportHeight()
+}
+
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.Mode = ReadMode
+		m.Viewport.Height = m.calculateViewportHeight()
+		return m, nil
+	case tea.KeyEnter:
+		m.Mode = ReadMode
+		m.Viewport.Height = m.calculateViewportHeight()
+		// An empty pattern repeats the last search, as in vim
+		if pattern := m.Search.Input.Value(); pattern != "" {
+			m.Search.Pattern = pattern
+		}
+		if m.Search.Pattern == "" {
+			return m, nil
+		}
+		m.Search.Backward = m.Search.Input.Prompt == "?"
+		// Start from the top of the screen
+		m.Search.At = searchMatch{Pair: m.CurrentPairIndex, Line: m.Viewport.YOffset, Start: -1}
+		m.Search.Found = false
+		m.searchNext(!m.Search.Backward)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.Search.Input, cmd = m.Search.Input.Update(msg)
+	return m, cmd
+}
+
+// searchNext moves to the next match after the current one, or the one
+// before it when forward is false, wrapping around the conversation
+func (m *Model) searchNext(forward bool) {
+	if m.Search.Pattern == "" {
+		return
+	}
+	matches := m.searchMatches()
+	if len(matches) == 0 {
+		m.Search.Found = false
+		m.Search.Total = 0
+		m.setNotice("Pattern not found: "+m.Search.Pattern, true)
+		m.updateViewport()
+		return
+	}
+
+	next := -1
+	if forward {
+		for i, match := range matches {
+			if m.Search.At.before(match) || (!m.Search.Found && m.Search.At == match) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = 0
+			m.setNotice("search hit BOTTOM, continuing at TOP", false)
+		}
+	} else {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i].before(m.Search.At) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = len(matches) - 1
+			m.setNotice("search hit TOP, continuing at BOTTOM", false)
+		}
+	}
+
+	match := matches[next]
+	m.Search.At = match
+	m.Search.Found = true
+	m.Search.Index = next
+	m.Search.Total = len(matches)
+	m.CurrentPairIndex = match.Pair
+	m.updateViewport()
+	if match.Line < m.Viewport.YOffset || match.Line >= m.Viewport.YOffset+m.Viewport.Height {
+		m.Viewport.SetYOffset(match.Line - m.Viewport.Height/3)
+	}
+}
+
+// clearSearch stops highlighting the last search
+func (m *Model) clearSearch() {
+	m.Search = search{}
+	m.updateViewport()
+}
+
+// highlightMatches marks the search pattern in pair i's rendered text, the
+// current match standing out from the rest
+func (m Model) highlightMatches(text string, i int) string {
+	if m.Search.Pattern == "" {
+		return text
+	}
+	re := searchPattern(m.Search.Pattern)
+	matchStyle := lipgloss.NewStyle().Reverse(true)
+	currentStyle := lipgloss.NewStyle().Background(lipgloss.Color("214")).Foreground(lipgloss.Color("0"))
+
+	lines := strings.Split(text, "\n")
+	for l, line := range lines {
+		matches := lineMatches(line, re)
+		// From the right, so earlier columns stay put
+		for k := len(matches) - 1; k >= 0; k-- {
+			start, end := matches[k][0], matches[k][1]
+			style := matchStyle
+			if m.Search.Found && m.Search.At == (searchMatch{Pair: i, Line: l, Start: start, End: end}) {
+				style = currentStyle
+			}
+			matched := ansi.Strip(ansi.Cut(line, start, end))
+			line = ansi.Truncate(line, start, "") + style.Render(matched) + ansi.TruncateLeft(line, end, "")
+		}
+		lines[l] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// searchStatus describes the current match for the status line, e.g.
+// "/tokio 2/5"
+func (m Model) searchStatus() string {
+	if m.Search.Pattern == "" || !m.Search.Found {
+		return ""
+	}
+	prefix := "/"
+	if m.Search.Backward {
+		prefix = "?"
+	}
+	return fmt.Sprintf("%s%s %d/%d", prefix, m.Search.Pattern, m.Search.Index+1, m.Search.Total)
+}
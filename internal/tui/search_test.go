@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchFor types pattern after / (or ? when backward) and presses enter
+func searchFor(t *testing.T, m Model, pattern string, backward bool) Model {
+	t.Helper()
+	key := '/'
+	if backward {
+		key = '?'
+	}
+	m = pressKey(t, m, key)
+	require.Equal(t, SearchMode, m.Mode)
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(pattern)})
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return updatedModel.(Model)
+}
+
+// rustPairs is a conversation mentioning Rust in two pairs
+var rustPairs = []MessagePair{
+	{Request: "Which language?", Response: "Rust has no garbage collector."},
+	{Request: "And Go?", Response: "Go has one."},
+	{Request: "Compare them", Response: "Go compiles faster than rust; rust is safer."},
+}
+
+func TestSearchJumpsAcrossPairs(t *testing.T) {
+	// Given a conversation focused on its first pair
+	m := newSessionModel(t, rustPairs...)
+
+	// When the user searches for "rust"
+	m = searchFor(t, m, "rust", false)
+
+	// Then the first match is focused, ignoring case
+	assert.Equal(t, ReadMode, m.Mode)
+	assert.Equal(t, 0, m.CurrentPairIndex)
+	assert.Contains(t, m.View(), "/rust 1/3")
+
+	// When the user presses "n"
+	m = pressKey(t, m, 'n')
+
+	// Then the search moves into the third pair, skipping the second
+	assert.Equal(t, 2, m.CurrentPairIndex)
+	assert.Contains(t, m.View(), "/rust 2/3")
+
+	// When the user presses "n" twice more
+	m = pressKey(t, m, 'n')
+	assert.Contains(t, m.View(), "/rust 3/3")
+	m = pressKey(t, m, 'n')
+
+	// Then the search wraps around to the first match
+	assert.Equal(t, 0, m.CurrentPairIndex)
+	assert.Contains(t, m.View(), "/rust 1/3")
+	assert.Contains(t, m.View(), "search hit BOTTOM")
+
+	// When the user presses "N"
+	m = pressKey(t, m, 'N')
+
+	// Then it goes back, wrapping to the last match
+	assert.Equal(t, 2, m.CurrentPairIndex)
+	assert.Contains(t, m.View(), "/rust 3/3")
+}
+
+func TestSearchBackward(t *testing.T) {
+	// Given a conversation focused on its last pair, scrolled past its text
+	m := newSessionModel(t, rustPairs...)
+	m.CurrentPairIndex = 2
+	m.updateViewport()
+
+	// When the user searches backward for "has"
+	m = searchFor(t, m, "has", true)
+
+	// Then the nearest match above is in the second pair
+	assert.Equal(t, 1, m.CurrentPairIndex)
+	assert.Contains(t, m.View(), "?has 2/2")
+
+	// And "n" keeps going backward
+	m = pressKey(t, m, 'n')
+	assert.Equal(t, 0, m.CurrentPairIndex)
+	assert.Contains(t, m.View(), "?has 1/2")
+}
+
+func TestSearchUpperCaseIsCaseSensitive(t *testing.T) {
+	// Given a conversation with "Rust" and "rust"
+	m := newSessionModel(t, rustPairs...)
+
+	// When the user searches for "Rust"
+	m = searchFor(t, m, "Rust", false)
+
+	// Then only the capitalised match is found
+	assert.Contains(t, m.View(), "/Rust 1/1")
+}
+
+func TestSearchReportsMissingPattern(t *testing.T) {
+	m := newSessionModel(t, rustPairs...)
+
+	m = searchFor(t, m, "python", false)
+
+	assert.Equal(t, 0, m.CurrentPairIndex)
+	assert.True(t, m.NoticeIsError)
+	assert.Contains(t, m.View(), "Pattern not found: python")
+}
+
+func TestSearchEscapeClearsHighlight(t *testing.T) {
+	// Given an active search
+	m := newSessionModel(t, rustPairs...)
+	m = searchFor(t, m, "rust", false)
+
+	// When the user presses escape
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updatedModel.(Model)
+
+	// Then the search is forgotten
+	assert.Empty(t, m.Search.Pattern)
+	assert.NotContains(t, m.View(), "/rust")
+}
+
+func TestHighlightMatchesKeepsText(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(profile)
+
+	// Given styled text and an active search
+	m := newSessionModel(t, rustPairs...)
+	m.Search = search{Pattern: "go", Found: true, At: searchMatch{Pair: 0, Line: 0, Start: 6, End: 8}}
+	text := lipgloss.NewStyle().Bold(true).Render("Go or go?")
+
+	// When matches are highlighted
+	highlighted := m.highlightMatches(text, 0)
+
+	// Then both matches are marked, without changing the text
+	assert.NotEqual(t, text, highlighted)
+	assert.Equal(t, "Go or go?", ansi.Strip(highlighted))
+	assert.Equal(t, 2, strings.Count(highlighted, "Go")+strings.Count(highlighted, "go"))
+}
@@ -0,0 +1,261 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tama/internal/ollama"
+	"tama/internal/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type sessionListMsg struct {
+	sessions []*session.Session
+	err      error
+}
+type sessionSearchMsg struct {
+	matches []session.Match
+	err     error
+}
+
+func listSessionsCmd(store *session.Store) tea.Cmd {
+	return func() tea.Msg {
+		sessions, err := store.List()
+		if err != nil {
+			return sessionListMsg{err: err}
+		}
+		return sessionListMsg{sessions: sessions}
+	}
+}
+
+func searchSessionsCmd(store *session.Store, query string) tea.Cmd {
+	return func() tea.Msg {
+		matches, err := store.Search(query)
+		if err != nil {
+			return sessionSearchMsg{err: err}
+		}
+		return sessionSearchMsg{matches: matches}
+	}
+}
+
+// saveSession writes the current conversation to the session store
+func (m *Model) saveSession() {
+	if m.Sessions == nil || m.Session == nil || len(m.MessagePairs) == 0 {
+		return
+	}
+	m.Session.Model = m.CurrentModel
+	m.Session.SystemPrompt = m.SystemPrompt
+	m.Session.Options = m.Options
+	m.Session.Think = m.Think
+	m.Session.Pairs = m.MessagePairs
+	m.Session.UpdatedAt = time.Now()
+	if err := m.Sessions.Save(m.Session); err != nil {
+		m.Err = fmt.Errorf("failed to save session: %w", err)
+	}
+}
+
+// LoadSession replaces the conversation with a saved session, focused on its
+// last message pair; new requests continue the session
+func (m *Model) LoadSession(s *session.Session) {
+	m.stopCompaction()
+	m.Session = s
+	m.MessagePairs = s.Pairs
+	m.CurrentPairIndex = max(len(s.Pairs)-1, 0)
+	if s.Model != "" {
+		m.CurrentModel = s.Model
+	}
+	m.SystemPrompt = s.SystemPrompt
+	m.Editing = false
+	m.forgetOversize()
+	m.Options = s.Options
+	m.Think = s.Think
+	m.ResponseLines = []string{}
+	m.updateViewport()
+	m.Viewport.GotoTop()
+}
+
+// newSession starts an empty conversation with the configured defaults; the
+// previous one stays saved with its own system prompt and options
+func (m *Model) newSession() {
+	m.stopCompaction()
+	m.Session = session.New()
+	m.MessagePairs = []MessagePair{}
+	m.CurrentPairIndex = 0
+	m.Editing = false
+	m.forgetOversize()
+	m.SystemPrompt = ""
+	m.Options = ollama.Options{}
+	m.Think = ""
+	m.Viewport.SetContent("")
+}
+
+// openSessionPicker shows the saved sessions, newest first
+func (m *Model) openSessionPicker() tea.Cmd {
+	m.openPicker(SessionPickerMode, "Sessions")
+	if m.Sessions == nil {
+		m.Picker.SetItems(nil)
+		return nil
+	}
+	return listSessionsCmd(m.Sessions)
+}
+
+// openSession switches to a saved session and shows it in read mode
+func (m *Model) openSession(id string) tea.Cmd {
+	if m.Session != nil && id == m.Session.ID {
+		return nil
+	}
+	return m.openSessionAt(id, -1)
+}
+
+// openSessionAt shows pair i of a saved session, which may be the current
+// one, in read mode; a negative i focuses the last pair
+func (m *Model) openSessionAt(id string, i int) tea.Cmd {
+	var cmd tea.Cmd
+	if m.Session == nil || id != m.Session.ID {
+		s, err := m.Sessions.Load(id)
+		if err != nil {
+			m.Err = err
+			return nil
+		}
+		m.LoadSession(s)
+		cmd = tea.Batch(checkModelStatus(m.Client, m.CurrentModel), contextSizeCmd(m.Client, m.CurrentModel))
+	}
+	if i >= 0 && i < len(m.MessagePairs) {
+		m.CurrentPairIndex = i
+		m.updateViewport()
+		m.Viewport.GotoTop()
+	}
+	m.Mode = ReadMode
+	m.Textarea.Blur()
+	m.Viewport.Height = m.calculateViewportHeight()
+	return cmd
+}
+
+// openSessionSearch lists the pairs of saved sessions matching query
+func (m *Model) openSessionSearch(query string) tea.Cmd {
+	m.openPicker(SessionSearchMode, fmt.Sprintf("Sessions matching %q", query))
+	if m.Sessions == nil {
+		m.Picker.SetItems(nil)
+		return nil
+	}
+	return searchSessionsCmd(m.Sessions, query)
+}
+
+// openMatch shows a search match in read mode, switching to the branch and
+// response it was found in
+func (m *Model) openMatch(id string, match session.Match) tea.Cmd {
+	cmd := m.openSessionAt(id, -1)
+	if m.Session == nil || m.Session.ID != id {
+		return cmd
+	}
+	changed := false
+	for _, b := range match.Branches {
+		if b.Pair >= len(m.MessagePairs) {
+			break
+		}
+		all := m.branches(b.Pair)
+		if b.Branch >= len(all) {
+			break
+		}
+		m.forgetSummary(b.Pair)
+		m.setBranches(b.Pair, all, b.Branch)
+		changed = true
+	}
+	if match.Pair >= 0 && match.Pair < len(m.MessagePairs) {
+		pair := &m.MessagePairs[match.Pair]
+		if all := pair.Responses(); match.Response != pair.Selected && match.Response < len(all) {
+			selectResponse(pair, all, match.Response)
+			changed = true
+		}
+		m.CurrentPairIndex = match.Pair
+	}
+	if changed {
+		m.saveSession()
+	}
+	m.updateViewport()
+	m.Viewport.GotoTop()
+	return cmd
+}
+
+// sessionSearchItems lists search matches, each chosen by the value
+// "<session id>#<pair index>#<response>#<pair>:<branch>,…" naming the
+// branches to take
+func sessionSearchItems(matches []session.Match, currentID string) []pickerItem {
+	items := make([]pickerItem, 0, len(matches))
+	for _, match := range matches {
+		s := match.Session
+		details := []string{
+			s.Title(),
+			fmt.Sprintf("MSG %d", match.Pair+1),
+			s.UpdatedAt.Format("Jan 2 15:04"),
+		}
+		if match.Model != "" {
+			details = append(details, match.Model)
+		}
+		if other := match.Other(); other != "" {
+			details = append(details, other)
+		}
+		if s.ID == currentID {
+			details = append(details, "current")
+		}
+		branches := make([]string, len(match.Branches))
+		for i, b := range match.Branches {
+			branches[i] = fmt.Sprintf("%d:%d", b.Pair, b.Branch)
+		}
+		items = append(items, pickerItem{
+			Title:  match.Snippet,
+			Detail: strings.Join(details, " • "),
+			Value:  fmt.Sprintf("%s#%d#%d#%s", s.ID, match.Pair, match.Response, strings.Join(branches, ",")),
+		})
+	}
+	return items
+}
+
+// parseMatchValue splits a value made by sessionSearchItems; a pair that
+// cannot be read is -1
+func parseMatchValue(value string) (id string, match session.Match) {
+	id, rest, _ := strings.Cut(value, "#")
+	fields := strings.Split(rest, "#")
+	match.Pair = -1
+	if len(fields) != 3 {
+		return id, match
+	}
+	pair, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return id, match
+	}
+	match.Response, _ = strconv.Atoi(fields[1])
+	for _, taken := range strings.Split(fields[2], ",") {
+		var b session.Branch
+		if _, err := fmt.Sscanf(taken, "%d:%d", &b.Pair, &b.Branch); err == nil {
+			match.Branches = append(match.Branches, b)
+		}
+	}
+	match.Pair = pair
+	return id, match
+}
+
+func sessionPickerItems(sessions []*session.Session, currentID string) []pickerItem {
+	items := make([]pickerItem, 0, len(sessions))
+	for _, s := range sessions {
+		details := []string{
+			s.UpdatedAt.Format("Jan 2 15:04"),
+			fmt.Sprintf("%d msgs", len(s.Pairs)),
+		}
+		if s.Model != "" {
+			details = append(details, s.Model)
+		}
+		if s.ID == currentID {
+			details = append(details, "current")
+		}
+		items = append(items, pickerItem{
+			Title:  s.Title(),
+			Detail: strings.Join(details, " • "),
+			Value:  s.ID,
+		})
+	}
+	return items
+}
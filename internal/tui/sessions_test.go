@@ -0,0 +1,304 @@
+package tui
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"tama/internal/history"
+	"tama/internal/ollama"
+	"tama/internal/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSessionModel is a read-mode conversation of pairs, saved to a session
+// store and recording prompts in a history of its own
+func newSessionModel(t *testing.T, pairs ...MessagePair) Model {
+	t.Helper()
+	m := newReadModeModel(t)
+	m.Sessions = session.NewStore(t.TempDir())
+	m.History = &history.History{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+	if len(pairs) > 0 {
+		m.MessagePairs = slices.Clone(pairs)
+		m.updateViewport()
+	}
+	return m
+}
+
+func TestConversationIsAutoSaved(t *testing.T) {
+	// Given a running tama with a session store
+	m := newSessionModel(t)
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+
+	// When the user sends a request
+	m.Textarea.SetValue("What is Go?")
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then the request is saved before the response arrives
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	require.Len(t, saved.Pairs, 1)
+	assert.Equal(t, "What is Go?", saved.Pairs[0].Request)
+	assert.False(t, saved.Pairs[0].RequestedAt.IsZero(), "Request time should be recorded")
+
+	// When the response completes
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "A programming language"})
+	m = updatedModel.(Model)
+
+	// Then the response, model and duration are saved
+	saved, err = m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "A programming language", saved.Pairs[0].Response)
+	assert.Equal(t, m.CurrentModel, saved.Pairs[0].Model)
+	assert.Equal(t, m.CurrentModel, saved.Model)
+	assert.True(t, saved.Pairs[0].Duration > 0, "Duration should be saved")
+}
+
+func TestCancelledFlagIsSaved(t *testing.T) {
+	// Given a request in progress
+	m := newSessionModel(t)
+	m.MessagePairs = []MessagePair{{Request: "Tell me a story"}}
+	m.IsWaiting = true
+	m.ChatRequested = true
+
+	// When the user cancels it
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updatedModel.(Model)
+
+	// Then the saved pair is marked cancelled
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.True(t, saved.Pairs[0].Cancelled)
+}
+
+func TestClearStartsNewSessionAndKeepsOld(t *testing.T) {
+	// Given a saved conversation with its own system prompt and options
+	m := newSessionModel(t)
+	m.MessagePairs = []MessagePair{{Request: "First", Response: "Answer"}}
+	m.SystemPrompt = "Talk like a pirate"
+	m.Think = "off"
+	require.NoError(t, m.Options.Set("seed", "7"))
+	m.saveSession()
+	oldID := m.Session.ID
+
+	// When the user clears the conversation
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	m.Textarea.SetValue("/clear")
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then the conversation is empty with a new session and the defaults
+	assert.Empty(t, m.MessagePairs)
+	assert.NotEqual(t, oldID, m.Session.ID)
+	assert.Empty(t, m.SystemPrompt)
+	assert.True(t, m.Options.IsZero())
+	assert.Empty(t, m.Think)
+
+	// And the old conversation can still be loaded
+	old, err := m.Sessions.Load(oldID)
+	require.NoError(t, err)
+	assert.Equal(t, "First", old.Pairs[0].Request)
+	assert.Equal(t, "Talk like a pirate", old.SystemPrompt)
+}
+
+func TestBrowseAndReopenSession(t *testing.T) {
+	// Given a saved session from earlier
+	m := newSessionModel(t)
+	earlier := session.New()
+	earlier.Model = "llama3.2:3b"
+	earlier.UpdatedAt = time.Now().Add(-time.Hour)
+	earlier.Pairs = []MessagePair{
+		{Request: "Earlier question", Response: "Earlier answer"},
+		{Request: "Follow-up", Response: "More"},
+	}
+	require.NoError(t, m.Sessions.Save(earlier))
+
+	// When the user presses "S"
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	m = updatedModel.(Model)
+	require.NotNil(t, cmd)
+	assert.Equal(t, SessionPickerMode, m.Mode)
+	updatedModel, _ = m.Update(cmd())
+	m = updatedModel.(Model)
+
+	// Then the saved session is listed
+	view := m.View()
+	assert.Contains(t, view, "Earlier question")
+	assert.Contains(t, view, "2 msgs")
+
+	// When the user selects it
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then the conversation is restored, focused on the last pair
+	assert.Equal(t, ReadMode, m.Mode)
+	assert.Equal(t, earlier.ID, m.Session.ID)
+	assert.Len(t, m.MessagePairs, 2)
+	assert.Equal(t, 1, m.CurrentPairIndex)
+	assert.Equal(t, "llama3.2:3b", m.CurrentModel)
+	assert.Contains(t, m.View(), "MSG 2/2")
+
+	// When the user continues the conversation
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	m.Textarea.SetValue("Third")
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then the new pair is saved into the same session
+	saved, err := m.Sessions.Load(earlier.ID)
+	require.NoError(t, err)
+	assert.Len(t, saved.Pairs, 3)
+}
+
+func TestResumedSessionKeepsItsModel(t *testing.T) {
+	// Given Ollama running a different model than the saved session used
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/ps":
+			fmt.Fprint(w, `{"models":[{"name":"qwen3:8b"}]}`)
+		case "/api/tags":
+			fmt.Fprint(w, `{"models":[{"name":"llama3.2:3b"},{"name":"qwen3:8b"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	m := InitialModel()
+	m.Client = ollama.NewClient(server.URL)
+	saved := session.New()
+	saved.Model = "llama3.2:3b"
+
+	// When the session is resumed and tama starts
+	m.LoadSession(saved)
+	batch, ok := m.Init()().(tea.BatchMsg)
+	require.True(t, ok)
+	for _, cmd := range batch {
+		updatedModel, _ := m.Update(cmd())
+		m = updatedModel.(Model)
+	}
+
+	// Then it continues with the session's model
+	assert.Equal(t, "llama3.2:3b", m.CurrentModel)
+	assert.False(t, m.ModelIsLoaded)
+}
+
+func TestSessionsCommandOpensBrowser(t *testing.T) {
+	m := newSessionModel(t)
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+
+	m.Textarea.SetValue("/sessions")
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	assert.Equal(t, SessionPickerMode, m.Mode)
+	assert.NotNil(t, cmd)
+	assert.Empty(t, m.MessagePairs, "Command should not be sent as a request")
+}
+
+func TestSearchCommandOpensMatchingPair(t *testing.T) {
+	// Given a saved session that mentions goroutines in its first pair
+	m := newSessionModel(t)
+	earlier := session.New()
+	earlier.Name = "Concurrency"
+	earlier.Pairs = []MessagePair{
+		{Request: "What is a goroutine?", Response: "A lightweight thread", Model: "qwen3:8b"},
+		{Request: "Thanks", Response: "You're welcome"},
+	}
+	require.NoError(t, m.Sessions.Save(earlier))
+
+	// When the user searches for it
+	m = pressKey(t, m, 'i')
+	m, cmd := submit(t, m, "/search goroutine")
+	require.NotNil(t, cmd)
+	assert.Equal(t, SessionSearchMode, m.Mode)
+	updatedModel, _ := m.Update(cmd())
+	m = updatedModel.(Model)
+
+	// Then the matching pair is listed with a snippet and its session
+	view := m.View()
+	assert.Contains(t, view, "What is a goroutine? A lightweight thread")
+	assert.Contains(t, view, "Concurrency • MSG 1")
+	assert.Contains(t, view, "qwen3:8b")
+
+	// When the user opens it
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then the session is shown focused on the matching pair
+	assert.Equal(t, ReadMode, m.Mode)
+	assert.Equal(t, earlier.ID, m.Session.ID)
+	assert.Equal(t, 0, m.CurrentPairIndex)
+	assert.Contains(t, m.View(), "MSG 1/2")
+}
+
+func TestSearchCommandOpensOtherBranchAndResponse(t *testing.T) {
+	// Given a saved session with a branch left by an edit and a response
+	// replaced by regenerating
+	m := newSessionModel(t)
+	earlier := session.New()
+	earlier.Pairs = []MessagePair{
+		{Request: "Hi", Response: "Hello"},
+		{
+			Request: "What is Go?", Response: "A language",
+			Branch:   1,
+			Siblings: [][]MessagePair{{{Request: "What is Rust?", Response: "A language with borrowing"}}},
+		},
+		{
+			Request: "Explain", Response: "Goroutines are cheap",
+			Alternatives: []session.Alternative{{Response: "Guard it with a mutex"}},
+		},
+	}
+	require.NoError(t, m.Sessions.Save(earlier))
+	search := func(query string) Model {
+		t.Helper()
+		m = pressKey(t, m, 'i')
+		m, cmd := submit(t, m, "/search "+query)
+		require.NotNil(t, cmd)
+		updatedModel, _ := m.Update(cmd())
+		return updatedModel.(Model)
+	}
+
+	// When the user searches for the text of the other response
+	m = search("mutex")
+
+	// Then it is listed as such
+	assert.Contains(t, m.View(), "MSG 3")
+	assert.Contains(t, m.View(), "other response")
+
+	// When they open it
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then that response is shown
+	assert.Equal(t, 2, m.CurrentPairIndex)
+	assert.Equal(t, "Guard it with a mutex", m.MessagePairs[2].Response)
+	assert.Contains(t, m.View(), "response 2/2")
+
+	// When they search for the text of the other branch and open it
+	m = search("borrowing")
+	assert.Contains(t, m.View(), "other branch")
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then that branch is shown, and stays active in the saved session
+	require.Len(t, m.MessagePairs, 2)
+	assert.Equal(t, 1, m.CurrentPairIndex)
+	assert.Equal(t, "What is Rust?", m.MessagePairs[1].Request)
+	assert.Contains(t, m.View(), "branch 1/2")
+	saved, err := m.Sessions.Load(earlier.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "What is Rust?", saved.Pairs[1].Request)
+}
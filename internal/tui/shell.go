@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"tama/internal/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxCommandOutput is how much of a command's output is sent; the rest is
+// cut
+const maxCommandOutput = maxAttachmentSize
+
+// shellRun is a request whose "!command" lines are waiting to be confirmed
+// or are running, in ShellConfirmMode
+type shellRun struct {
+	Input       string // Request as typed
+	Commands    []string
+	Attachments []session.Attachment
+	cancel      func() // Stops the commands once they are running
+}
+
+// shellDoneMsg carries what the commands of a request printed
+type shellDoneMsg struct {
+	commands []session.Command
+	stopped  bool // Whether the user stopped them
+}
+
+// confirmShell asks before running the commands of a request
+func (m *Model) confirmShell(run shellRun) {
+	m.Shell = run
+	m.Mode = ShellConfirmMode
+	m.Textarea.Blur()
+	m.Viewport.Height = m.calculateViewportHeight()
+}
+
+// closeShell returns to the prompt, which still holds the request
+func (m *Model) closeShell() {
+	m.Shell = shellRun{}
+	m.Mode = PromptMode
+	m.Textarea.Focus()
+	m.Viewport.Height = m.calculateViewportHeight()
+}
+
+func (m Model) updateShell(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.Shell.cancel != nil {
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC {
+			// Reported as stopped by shellDoneMsg
+			m.Shell.cancel()
+		}
+		return m, nil
+	}
+	switch msg.String() {
+	case "y":
+		ctx, cancel := context.WithCancel(context.Background())
+		m.Shell.cancel = cancel
+		return m, runShellCmd(ctx, cancel, m.Shell.Commands)
+	case "n", "esc", "ctrl+c":
+		m.closeShell()
+		m.forgetOversize()
+	}
+	return m, nil
+}
+
+// runShellCmd runs commands one after another, each stopped after
+// runTimeout
+func runShellCmd(ctx context.Context, cancel func(), commands []string) tea.Cmd {
+	return func() tea.Msg {
+		defer cancel()
+		var results []session.Command
+		for _, command := range commands {
+			result := runShell(ctx, command)
+			if ctx.Err() != nil {
+				return shellDoneMsg{stopped: true}
+			}
+			results = append(results, result)
+		}
+		return shellDoneMsg{commands: results}
+	}
+}
+
+// runShell runs command with sh in the current directory, capturing its
+// output
+func runShell(ctx context.Context, command string) session.Command {
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+	output, err := shellCmd(ctx, command).CombinedOutput()
+
+	var cut string
+	if len(output) > maxCommandOutput {
+		output = output[:maxCommandOutput]
+		cut = fmt.Sprintf("\n… cut at %s", formatSize(maxCommandOutput))
+	}
+	result := session.Command{Command: command, Output: strings.ToValidUTF8(string(output), "�") + cut}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Error = fmt.Sprintf("stopped after %s", runTimeout)
+	case err != nil:
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// finishShell sends the request with the output of its commands, or returns
+// to the prompt if they were stopped
+func (m Model) finishShell(msg shellDoneMsg) (tea.Model, tea.Cmd) {
+	if m.Mode != ShellConfirmMode {
+		return m, nil
+	}
+	run := m.Shell
+	m.closeShell()
+	if msg.stopped {
+		m.forgetOversize()
+		m.setNotice("commands stopped; nothing sent", false)
+		return m, nil
+	}
+	return m.sendRequest(run.Input, run.Attachments, msg.commands)
+}
+
+// View shows the commands to confirm, or running, in place of the prompt
+func (r shellRun) View() string {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	var b strings.Builder
+	if r.cancel != nil {
+		b.WriteString("Running, ctrl-c to stop:\n")
+	} else {
+		b.WriteString("Run these commands and send their output with the request?\n")
+	}
+	for _, command := range r.Commands {
+		b.WriteString("  $ " + command + "\n")
+	}
+	if r.cancel == nil {
+		b.WriteString(dimStyle.Render("y run • n back to the prompt"))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// viewHeight is the number of lines View takes
+func (r shellRun) viewHeight() int {
+	if r.cancel != nil {
+		return len(r.Commands) + 1
+	}
+	return len(r.Commands) + 2
+}
+
+// renderCommands shows the commands run for pair and their output, which
+// expands with o
+func (m *Model) renderCommands(pair MessagePair) string {
+	var b strings.Builder
+	for _, c := range pair.Commands {
+		text := strings.TrimRight(c.Output, "\n")
+		if c.Error != "" {
+			text += "\n(" + c.Error + ")"
+		}
+		if strings.TrimSpace(text) == "" {
+			text = "(no output)"
+		}
+		b.WriteString(m.renderSection("$ "+c.Command, 'o', text, m.CommandOutputExpanded))
+	}
+	return b.String()
+}
@@ -0,0 +1,314 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"tama/internal/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runConfirmed presses y to run the commands waiting to be confirmed and
+// hands their output back to the model
+func runConfirmed(t *testing.T, m Model) (Model, tea.Cmd) {
+	t.Helper()
+	m = pressKey(t, m, 'y')
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updatedModel.(Model)
+	require.Nil(t, cmd, "Pressing y again while running does nothing")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runShellCmd(ctx, cancel, m.Shell.Commands)()
+	updatedModel, cmd = m.Update(done)
+	return updatedModel.(Model), cmd
+}
+
+func TestSendRequestWithCommandOutput(t *testing.T) {
+	// Given a project
+	inProject(t, map[string]string{"notes.txt": "remember\n"})
+	m := newPromptModeModel(t)
+
+	// When the user sends a request with a ! line
+	m, cmd := submit(t, m, "What does this say?\n!cat notes.txt")
+
+	// Then they are asked before it runs, and nothing is sent yet
+	assert.Nil(t, cmd)
+	assert.Equal(t, ShellConfirmMode, m.Mode)
+	assert.Empty(t, m.MessagePairs)
+	view := m.View()
+	assert.Contains(t, view, "$ cat notes.txt")
+	assert.Contains(t, view, "y run • n back to the prompt")
+
+	// When they confirm
+	m, cmd = runConfirmed(t, m)
+
+	// Then the request is sent with the command's output stored on it
+	assert.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	pair := m.MessagePairs[0]
+	assert.Equal(t, "What does this say?\n!cat notes.txt", pair.Request)
+	assert.Equal(t, []session.Command{{Command: "cat notes.txt", Output: "remember\n"}}, pair.Commands)
+
+	// And the model is sent the output in place of the line
+	messages := m.newChatRequest(m.MessagePairs).Messages
+	assert.Equal(t, "What does this say?\n```console\n$ cat notes.txt\nremember\n```", messages[len(messages)-1].Content)
+}
+
+func TestOversizedCommandOutputWarnsWithoutRunningAgain(t *testing.T) {
+	// Given a command printing more than fits in the context window
+	inProject(t, nil)
+	m := newPromptModeModel(t)
+	m.ContextLength = 2048
+	input := "Summarize\n!echo ran >> runs.txt; head -c 20000 /dev/zero | tr '\\0' x"
+
+	// When the user sends it and it runs
+	m, _ = submit(t, m, input)
+	m, cmd := runConfirmed(t, m)
+
+	// Then they are warned instead of it being sent
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.MessagePairs)
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Contains(t, m.Notice, "more than fits in the 2.0k-token context")
+	assert.Equal(t, input, m.Textarea.Value())
+
+	// When they send it again
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+
+	// Then it is sent with the output of the first run, without running again
+	assert.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	require.Len(t, m.MessagePairs[0].Commands, 1)
+	assert.Len(t, m.MessagePairs[0].Commands[0].Output, 20000)
+	runs, err := os.ReadFile("runs.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "ran\n", string(runs))
+}
+
+// oversizedOutput is a request whose command prints more than fits in a
+// 2048-token context
+const oversizedOutput = "Summarize\n!head -c 20000 /dev/zero | tr '\\0' x"
+
+// refusedOutputModel is a prompt whose request was refused for the size of
+// its command's output, which is kept for sending it again
+func refusedOutputModel(t *testing.T) Model {
+	t.Helper()
+	inProject(t, nil)
+	m := newSessionModel(t)
+	m = pressKey(t, m, 'i')
+	m.ContextLength = 2048
+	m, _ = submit(t, m, oversizedOutput)
+	m, _ = runConfirmed(t, m)
+	require.Equal(t, oversizedOutput, m.OversizeConfirmed)
+	require.NotNil(t, m.OversizeCommands)
+	return m
+}
+
+func TestOversizeWarningIsForgottenWhenThePromptChanges(t *testing.T) {
+	// Given a request refused for the size of its command's output
+	m := refusedOutputModel(t)
+
+	// When the user types and deletes a character, leaving the same text
+	m = typeText(t, m, "x")
+	m, _ = pressType(t, m, tea.KeyBackspace)
+	require.Equal(t, oversizedOutput, m.Textarea.Value())
+
+	// Then sending it asks to run the command again
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+	assert.Equal(t, ShellConfirmMode, m.Mode)
+	assert.Empty(t, m.MessagePairs)
+}
+
+func TestOversizeWarningIsForgottenOnClear(t *testing.T) {
+	m := refusedOutputModel(t)
+
+	m.newSession()
+
+	assert.Empty(t, m.OversizeConfirmed)
+	assert.Nil(t, m.OversizeCommands)
+}
+
+func TestOversizeWarningIsForgottenOnLoadingASession(t *testing.T) {
+	m := refusedOutputModel(t)
+
+	m.LoadSession(session.New())
+
+	assert.Empty(t, m.OversizeConfirmed)
+	assert.Nil(t, m.OversizeCommands)
+}
+
+func TestOversizeWarningIsForgottenWhenCommandsAreDeclined(t *testing.T) {
+	// Given a request warned about before its command was run
+	inProject(t, map[string]string{"dump.txt": strings.Repeat("lorem ipsum ", 2000)})
+	m := newPromptModeModel(t)
+	m.ContextLength = 2048
+	input := "Compare @dump.txt\n!echo hi"
+	m, _ = submit(t, m, input)
+	require.Equal(t, input, m.OversizeConfirmed)
+
+	// When the user sends it again but declines the command
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+	require.Equal(t, ShellConfirmMode, m.Mode)
+	m = pressKey(t, m, 'n')
+
+	// Then the next send warns again
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Contains(t, m.Notice, "more than fits in the 2.0k-token context")
+}
+
+func TestOversizedAttachmentWarnsBeforeRunningCommands(t *testing.T) {
+	// Given a file bigger than the model's context window
+	inProject(t, map[string]string{"dump.txt": strings.Repeat("lorem ipsum ", 2000)})
+	m := newPromptModeModel(t)
+	m.ContextLength = 2048
+
+	// When the user sends a request attaching it and running a command
+	m, cmd := submit(t, m, "Compare @dump.txt\n!echo hi")
+
+	// Then they are warned before the command is offered to run
+	assert.Nil(t, cmd)
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Contains(t, m.Notice, "more than fits in the 2.0k-token context")
+
+	// When they send it again and confirm the command
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(Model)
+	require.Equal(t, ShellConfirmMode, m.Mode)
+	m, cmd = runConfirmed(t, m)
+
+	// Then it is sent without a second warning
+	assert.NotNil(t, cmd)
+	assert.Len(t, m.MessagePairs, 1)
+}
+
+func TestDecliningCommandsKeepsPrompt(t *testing.T) {
+	// Given a request with a ! line waiting to be confirmed
+	m := newPromptModeModel(t)
+	m, _ = submit(t, m, "!rm -rf build")
+	require.Equal(t, ShellConfirmMode, m.Mode)
+
+	// When the user presses n
+	m = pressKey(t, m, 'n')
+
+	// Then they are back at the prompt with the request as typed
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.True(t, m.Textarea.Focused())
+	assert.Equal(t, "!rm -rf build", m.Textarea.Value())
+	assert.Empty(t, m.MessagePairs)
+}
+
+func TestStoppedCommandsSendNothing(t *testing.T) {
+	// Given a command that is running
+	m := newPromptModeModel(t)
+	m, _ = submit(t, m, "!sleep 10")
+	m = pressKey(t, m, 'y')
+	require.NotNil(t, m.Shell.cancel)
+	assert.Contains(t, m.View(), "Running, ctrl-c to stop")
+
+	// When the user presses ctrl+c
+	m, cmd := pressType(t, m, tea.KeyCtrlC)
+	assert.Nil(t, cmd)
+	updatedModel, cmd := m.Update(shellDoneMsg{stopped: true})
+	m = updatedModel.(Model)
+
+	// Then nothing is sent and the prompt still holds the request
+	assert.Nil(t, cmd)
+	assert.Empty(t, m.MessagePairs)
+	assert.Equal(t, PromptMode, m.Mode)
+	assert.Equal(t, "commands stopped; nothing sent", m.Notice)
+	assert.Equal(t, "!sleep 10", m.Textarea.Value())
+}
+
+func TestRunShellRecordsFailures(t *testing.T) {
+	result := runShell(context.Background(), "echo oops >&2; exit 3")
+	assert.Equal(t, session.Command{Command: "echo oops >&2; exit 3", Output: "oops\n", Error: "exit status 3"}, result)
+
+	result = runShell(context.Background(), "head -c 300000 /dev/zero | tr '\\0' x")
+	assert.Len(t, result.Output, maxCommandOutput+len("\n… cut at 256.0 KB"))
+	assert.Empty(t, result.Error)
+}
+
+func TestRunShellStopsChildProcesses(t *testing.T) {
+	// Given a command whose shell starts a child that outlives the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+
+	// When it is run
+	result := runShell(ctx, "echo hi; sleep 5; echo bye")
+
+	// Then it is stopped at the timeout with what it printed so far
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, "hi\n", result.Output)
+	assert.Equal(t, "stopped after 1m0s", result.Error)
+}
+
+func TestRunCommand(t *testing.T) {
+	// Given a prompt
+	m := newPromptModeModel(t)
+
+	// When the user runs /run without a command
+	m, _ = submit(t, m, "/run")
+
+	// Then its usage is shown
+	assert.True(t, m.NoticeIsError)
+	assert.Contains(t, m.Notice, "/run <command>")
+
+	// When the user runs /run with a command
+	m, _ = submit(t, m, "/run git status")
+
+	// Then it waits to be confirmed like a request of a ! line
+	assert.Equal(t, ShellConfirmMode, m.Mode)
+	assert.Equal(t, []string{"git status"}, m.Shell.Commands)
+	assert.Equal(t, "!git status", m.Shell.Input)
+}
+
+func TestRunCommandWithSeveralLines(t *testing.T) {
+	// Given a prompt
+	inProject(t, nil)
+	m := newPromptModeModel(t)
+
+	// When the user runs /run with a command on each of two lines
+	m, _ = submit(t, m, "/run echo one\n\n  echo two")
+
+	// Then each line is a command of its own
+	require.Equal(t, ShellConfirmMode, m.Mode)
+	assert.Equal(t, []string{"echo one", "echo two"}, m.Shell.Commands)
+	assert.Equal(t, "!echo one\n!echo two", m.Shell.Input)
+
+	// And once they run, the output of both is sent
+	m, cmd := runConfirmed(t, m)
+	require.NotNil(t, cmd)
+	require.Len(t, m.MessagePairs, 1)
+	assert.Equal(t, "```console\n$ echo one\none\n```\n```console\n$ echo two\ntwo\n```", m.MessagePairs[0].Prompt())
+}
+
+func TestCommandOutputExpandsWithO(t *testing.T) {
+	// Given a request sent with a command's output
+	m := newSessionModel(t, threePairs...)
+	m.MessagePairs[2].Commands = []session.Command{{Command: "git log --oneline", Output: "abc123 Fix typo\ndef456 Add tests\n"}}
+	m.CurrentPairIndex = 2
+	m.updateViewport()
+
+	// Then the command is shown with the first line of its output
+	view := m.Viewport.View()
+	assert.Contains(t, view, "$ git log --oneline (o to expand)")
+	assert.Contains(t, view, "abc123 Fix typo …")
+	assert.NotContains(t, view, "Add tests")
+
+	// When the user presses o
+	m = pressKey(t, m, 'o')
+
+	// Then all of the output is shown
+	assert.Contains(t, m.Viewport.View(), "def456 Add tests")
+}
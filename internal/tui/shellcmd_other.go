@@ -0,0 +1,17 @@
+//go:build !unix
+
+package tui
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// shellCmd runs script with sh. Once it is stopped, its output is closed
+// after a second even if processes it started are still running.
+func shellCmd(ctx context.Context, script string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	cmd.WaitDelay = time.Second
+	return cmd
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package tui
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// shellCmd runs script with sh in a process group of its own. Stopping it
+// kills the whole group, since a child left running would keep the output
+// open and the command waiting.
+func shellCmd(ctx context.Context, script string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "sh", "-c", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	return cmd
+}
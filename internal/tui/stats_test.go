@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseBorderShowsStatistics(t *testing.T) {
+	// Given a request whose final chunk reports statistics
+	m := newSessionModel(t)
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	m, _ = submit(t, m, "Hi")
+	updatedModel, _ = m.Update(ResponseMetricsMsg{RequestID: m.RequestID, Metrics: ollama.Metrics{
+		PromptEvalCount: 1200,
+		EvalCount:       300,
+		EvalDuration:    2 * time.Second,
+		LoadDuration:    1200 * time.Millisecond,
+		DoneReason:      "stop",
+	}})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Hello!"})
+	m = updatedModel.(Model)
+
+	// Then they are kept on the pair and shown in the response border
+	assert.Equal(t, 300, m.MessagePairs[0].Metrics.EvalCount)
+	view := m.Viewport.View()
+	assert.Contains(t, view, "300 tokens, 150.0 tok/s, load 1.2s)")
+	assert.NotContains(t, view, "truncated")
+
+	// And totalled in the status line
+	assert.Contains(t, m.View(), "Tokens: 1.2k in / 300 out")
+
+	// And saved with the session
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1200, saved.Pairs[0].Metrics.PromptEvalCount)
+}
+
+func TestResponseBorderWarnsWhenTruncated(t *testing.T) {
+	m := newReadModeModel(t)
+	m.MessagePairs = []MessagePair{{
+		Request:  "Write an essay",
+		Response: "Once upon a",
+		Metrics:  ollama.Metrics{EvalCount: 128, EvalDuration: time.Second, DoneReason: "length"},
+	}}
+	m.updateViewport()
+
+	view := m.Viewport.View()
+	assert.Contains(t, view, "128 tokens, 128.0 tok/s)")
+	assert.NotContains(t, view, "load", "A model already in memory has no load time worth showing")
+	assert.Contains(t, view, "⚠ truncated at length limit")
+}
+
+func TestNoTokenSummaryWithoutStatistics(t *testing.T) {
+	m := newReadModeModel(t)
+	m.MessagePairs = []MessagePair{{Request: "Hi", Response: "Hello"}}
+
+	assert.NotContains(t, m.View(), "Tokens:")
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendChatRequestReportsStreamErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Partial"}}`)
+		fmt.Fprintln(w, `{"error":"model runner has unexpectedly stopped"}`)
+	}))
+	defer server.Close()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cmd := sendChatRequestCmd(ollama.ChatRequest{Model: "m"}, 1, func(tea.Msg) {}, ctx, cancelFn, ollama.NewClient(server.URL))
+
+	failed, ok := cmd().(responseFailedMsg)
+	require.True(t, ok, "A stream error fails the response")
+	assert.Equal(t, "Partial", failed.response)
+	assert.EqualError(t, failed.err, "ollama: model runner has unexpectedly stopped")
+}
+
+func TestSendChatRequestReportsDroppedConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Partial"}}`)
+	}))
+	defer server.Close()
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cmd := sendChatRequestCmd(ollama.ChatRequest{Model: "m"}, 1, func(tea.Msg) {}, ctx, cancelFn, ollama.NewClient(server.URL))
+
+	failed, ok := cmd().(responseFailedMsg)
+	require.True(t, ok, "A stream ending before done fails the response")
+	assert.ErrorIs(t, failed.err, ollama.ErrIncomplete)
+}
+
+func TestFailedResponseIsShownOnThePair(t *testing.T) {
+	// Given a request whose stream failed part way
+	m := newSessionModel(t)
+	m = pressKey(t, m, 'i')
+	m, _ = submit(t, m, "Hi")
+	updatedModel, _ := m.Update(responseFailedMsg{requestID: m.RequestID, response: "Hel", err: ollama.ErrIncomplete})
+	m = updatedModel.(Model)
+
+	// Then the partial response is kept with the error, and the request is over
+	pair := m.MessagePairs[0]
+	assert.Equal(t, "Hel", pair.Response)
+	assert.Equal(t, ollama.ErrIncomplete.Error(), pair.Error)
+	assert.False(t, m.ChatRequested)
+	assert.Equal(t, ReadMode, m.Mode)
+	view := m.Viewport.View()
+	assert.Contains(t, view, "Response (failed")
+	assert.Contains(t, view, "⚠ Response failed")
+
+	// And the failed pair is left out of the next request
+	m = pressKey(t, m, 'i')
+	m, _ = submit(t, m, "Again")
+	req := m.newChatRequest(m.MessagePairs)
+	assert.Equal(t, []ollama.Message{{Role: "user", Content: "Again"}}, req.Messages)
+}
@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"tama/internal/config"
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemPromptLeadsEveryRequest(t *testing.T) {
+	pairs := []MessagePair{
+		{Request: "First", Response: "One"},
+		{Request: "Second"},
+	}
+
+	messages := buildChatMessages("You are terse.", pairs)
+
+	require.Len(t, messages, 4)
+	assert.Equal(t, ollama.Message{Role: "system", Content: "You are terse."}, messages[0])
+	assert.Equal(t, "user", messages[1].Role)
+	assert.Equal(t, "Second", messages[3].Content)
+}
+
+func TestSystemCommandSetsSessionPrompt(t *testing.T) {
+	// Given a running tama with a per-model default
+	m := newSessionModel(t)
+	m.CurrentModel = "qwen3:8b"
+	m.Config = config.Config{SystemPrompts: map[string]string{"qwen3:8b": "Answer in English."}}
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	assert.Equal(t, "Answer in English.", m.systemPrompt(), "Config default applies")
+
+	// When the user sets a system prompt
+	m, _ = submit(t, m, "/system You are a pirate.")
+
+	// Then it overrides the default and is sent with the request
+	assert.Equal(t, "You are a pirate.", m.systemPrompt())
+	req := m.newChatRequest([]MessagePair{{Request: "Hi"}})
+	assert.Equal(t, "system", req.Messages[0].Role)
+	assert.Equal(t, "You are a pirate.", req.Messages[0].Content)
+
+	// And it is saved with the session
+	m, _ = submit(t, m, "Hello")
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "You are a pirate.", saved.SystemPrompt)
+
+	// When the user resets it
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Arr"})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	m, _ = submit(t, m, "/system")
+
+	// Then the model default applies again
+	assert.Equal(t, "Answer in English.", m.systemPrompt())
+}
+
+func TestNoSystemMessageWithoutPrompt(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	req := m.newChatRequest([]MessagePair{{Request: "Hi"}})
+
+	require.Len(t, req.Messages, 1)
+	assert.Equal(t, "user", req.Messages[0].Role)
+}
+
+func TestSystemPromptHeaderCollapsible(t *testing.T) {
+	// Given a conversation with a multi-line system prompt
+	m := newReadModeModel(t)
+	m.SystemPrompt = "You are a careful reviewer.\nPoint out bugs first."
+	m.MessagePairs = []MessagePair{
+		{Request: "First", Response: "One"},
+		{Request: "Second", Response: "Two"},
+	}
+	m.updateViewport()
+
+	// Then a collapsed header shows above the first pair
+	view := m.Viewport.View()
+	assert.Contains(t, view, "System (s to expand)")
+	assert.Contains(t, view, "You are a careful reviewer. …")
+	assert.NotContains(t, view, "Point out bugs first.")
+	assert.Less(t, strings.Index(view, "System"), strings.Index(view, "Request"), "Header should be above the request")
+
+	// When the user presses "s"
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = updatedModel.(Model)
+
+	// Then the full prompt is shown
+	view = m.Viewport.View()
+	assert.Contains(t, view, "System (s to collapse)")
+	assert.Contains(t, view, "Point out bugs first.")
+
+	// And the header is not repeated on later pairs
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}})
+	m = updatedModel.(Model)
+	assert.NotContains(t, m.Viewport.View(), "System")
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tama/internal/ollama"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendChatRequestStreamsThinkingSeparately(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"","thinking":"The user"}}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"","thinking":" says hi."}}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hello!"},"done":true}`)
+	}))
+	defer server.Close()
+
+	var sent []tea.Msg
+	ctx, cancelFn := context.WithCancel(context.Background())
+	cmd := sendChatRequestCmd(ollama.ChatRequest{Model: "gpt-oss:20b"}, 7, func(msg tea.Msg) { sent = append(sent, msg) }, ctx, cancelFn, ollama.NewClient(server.URL))
+
+	result := cmd()
+
+	assert.Equal(t, ResponseCompleteMsg{RequestID: 7, Response: "Hello!"}, result, "Thinking is kept out of the response")
+	assert.Equal(t, []tea.Msg{
+		ThinkingLineMsg{RequestID: 7, Thinking: "The user"},
+		ThinkingLineMsg{RequestID: 7, Thinking: "The user says hi."},
+		ResponseLineMsg{RequestID: 7, Text: "Hello!"},
+		ResponseMetricsMsg{RequestID: 7},
+	}, sent)
+}
+
+func TestThinkingSectionBetweenRequestAndResponse(t *testing.T) {
+	// Given a request that is streaming its reasoning
+	m := newSessionModel(t)
+	updatedModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	m = updatedModel.(Model)
+	m, _ = submit(t, m, "Hi")
+	updatedModel, _ = m.Update(ThinkingLineMsg{RequestID: m.RequestID, Thinking: "The user greets me.\nI should greet back."})
+	m = updatedModel.(Model)
+	updatedModel, _ = m.Update(ResponseCompleteMsg{RequestID: m.RequestID, Response: "Hello!"})
+	m = updatedModel.(Model)
+
+	// Then the reasoning is kept on the pair and shown collapsed
+	assert.Equal(t, "The user greets me.\nI should greet back.", m.MessagePairs[0].Thinking)
+	view := m.Viewport.View()
+	assert.Contains(t, view, "Thinking (t to expand)")
+	assert.Contains(t, view, "The user greets me. …")
+	assert.NotContains(t, view, "I should greet back.")
+	assert.Less(t, strings.Index(view, "Request"), strings.Index(view, "Thinking"))
+	assert.Less(t, strings.Index(view, "Thinking"), strings.Index(view, "Response"))
+
+	// When the user presses "t"
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = updatedModel.(Model)
+
+	// Then the full reasoning is shown
+	view = m.Viewport.View()
+	assert.Contains(t, view, "Thinking (t to collapse)")
+	assert.Contains(t, view, "I should greet back.")
+
+	// And it is saved with the session
+	saved, err := m.Sessions.Load(m.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, m.MessagePairs[0].Thinking, saved.Pairs[0].Thinking)
+}
+
+func TestSetThink(t *testing.T) {
+	m := newPromptModeModel(t)
+
+	m, _ = submit(t, m, "/set think high")
+
+	assert.Equal(t, ollama.Think("high"), m.newChatRequest(nil).Think)
+	assert.Contains(t, m.View(), "think=high")
+
+	m, _ = submit(t, m, "/set think maybe")
+	assert.True(t, m.NoticeIsError)
+	assert.Equal(t, ollama.Think("high"), m.Think)
+}
@@ -1,25 +1,30 @@
 package tui
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
+	"tama/internal/ollama"
+	"tama/internal/session"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 )
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		checkRunningModel(),
-		checkModelStatus(m.CurrentModel),
-	)
+	cmds := []tea.Cmd{
+		checkModelStatus(m.Client, m.CurrentModel),
+		listModelsCmd(m.Client),
+		contextSizeCmd(m.Client, m.CurrentModel),
+	}
+	if m.CurrentModel == "" {
+		// Start with the running or last used model, unless a resumed
+		// session brought its own
+		cmds = append(cmds, checkRunningModel(m.Client))
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -32,20 +37,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.BlurMsg:
 		m.Textarea.Blur()
 	case tea.KeyMsg:
+		m.Notice = ""
+		yanking := m.PendingYank
+		m.PendingYank = false
+		ctrlX := m.PendingCtrlX
+		m.PendingCtrlX = false
+		if m.isPickerMode() {
+			return m.updatePicker(msg)
+		}
+		if m.Mode == SearchMode {
+			return m.updateSearch(msg)
+		}
+		if m.Mode == CodeBlockMode {
+			return m.updateCodeBlocks(msg)
+		}
+		if m.Mode == ShellConfirmMode {
+			return m.updateShell(msg)
+		}
+		if msg.Paste && (m.Mode == PromptMode || m.Mode == ReadMode) {
+			return m.paste(msg)
+		}
+		if m.Mode == PromptMode && m.isNewlineKey(msg) {
+			m.Textarea.InsertRune('\n')
+			m.fitPrompt()
+			return m, nil
+		}
+		if m.Mode == PromptMode && m.isSubmitKey(msg) {
+			if !m.Textarea.Focused() {
+				m.Textarea.Focus()
+				return m, nil
+			}
+			return m.submitPrompt()
+		}
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			// If waiting for a response, cancel it instead of quitting
 			if m.IsWaiting || m.ChatRequested {
-				m.IsWaiting = false
-				m.ChatRequested = false
-				if m.cancelCurrentRequestFn != nil {
-					m.cancelCurrentRequestFn()
-					m.cancelCurrentRequestFn = nil
-				}
-				// Mark the current message as cancelled
-				if len(m.MessagePairs) > 0 && m.CurrentPairIndex < len(m.MessagePairs) {
-					m.MessagePairs[m.CurrentPairIndex].Cancelled = true
-				}
+				m.cancelRequest()
 				return m, nil
 			}
 			// Otherwise, quit the app
@@ -55,10 +83,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.Mode == PromptMode {
 				m.Mode = ReadMode
 				m.Textarea.Blur()
+				if m.Editing {
+					// Abandon the edit
+					m.Editing = false
+					m.setPrompt("")
+				}
+			} else if m.Search.Pattern != "" {
+				// Stop highlighting the last search
+				m.clearSearch()
 			}
 			m.Viewport.Height = m.calculateViewportHeight()
 			return m, nil
 		case tea.KeyRunes:
+			// Handle the key after 'y', choosing what to copy
+			if yanking && len(msg.Runes) == 1 && m.Mode == ReadMode {
+				return m, m.yank(msg.Runes[0])
+			}
+			// Handle 'y' key to start copying part of the focused pair
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'y' && m.Mode == ReadMode {
+				if len(m.MessagePairs) > 0 {
+					m.PendingYank = true
+					m.setNotice("copy: y response • r request • 1-9 code block", false)
+				}
+				return m, nil
+			}
 			// Handle 'i' key to enter prompt mode from read mode
 			if len(msg.Runes) == 1 && msg.Runes[0] == 'i' && m.Mode == ReadMode {
 				// Don't allow entering prompt mode while waiting for a response
@@ -93,6 +141,105 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(msg.Runes) == 1 && m.Mode == ReadMode {
 				m.LastKeyWasG = false
 			}
+			// Handle 'm' key to open the model picker
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'm' && m.Mode == ReadMode {
+				// Don't allow switching models while waiting for a response
+				if m.IsWaiting || m.ChatRequested {
+					return m, nil
+				}
+				return m, m.openModelPicker()
+			}
+			// Handle 'S' key to browse saved sessions
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'S' && m.Mode == ReadMode {
+				if m.IsWaiting || m.ChatRequested {
+					return m, nil
+				}
+				return m, m.openSessionPicker()
+			}
+			// Handle 's' key to expand or collapse the system prompt and summary
+			if len(msg.Runes) == 1 && msg.Runes[0] == 's' && m.Mode == ReadMode {
+				m.SystemPromptExpanded = !m.SystemPromptExpanded
+				m.updateViewport()
+				return m, nil
+			}
+			// Handle 't' key to expand or collapse the thinking section
+			if len(msg.Runes) == 1 && msg.Runes[0] == 't' && m.Mode == ReadMode {
+				m.ThinkingExpanded = !m.ThinkingExpanded
+				m.updateViewport()
+				return m, nil
+			}
+			// Handle 'o' key to expand or collapse the output of the
+			// request's ! commands
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'o' && m.Mode == ReadMode {
+				m.CommandOutputExpanded = !m.CommandOutputExpanded
+				m.updateViewport()
+				return m, nil
+			}
+			// Handle 'c' key to edit the focused request and resend it as a
+			// new branch
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'c' && m.Mode == ReadMode {
+				if m.IsWaiting || m.ChatRequested {
+					return m, nil
+				}
+				m.startEdit()
+				return m, nil
+			}
+			// Handle '<' and '>' keys to cycle the focused pair's branches
+			if len(msg.Runes) == 1 && (msg.Runes[0] == '<' || msg.Runes[0] == '>') && m.Mode == ReadMode {
+				if m.IsWaiting || m.ChatRequested {
+					return m, nil
+				}
+				if msg.Runes[0] == '<' {
+					m.switchBranch(-1)
+				} else {
+					m.switchBranch(1)
+				}
+				return m, nil
+			}
+			// Handle 'r' key to regenerate the focused response
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'r' && m.Mode == ReadMode {
+				if m.IsWaiting || m.ChatRequested || len(m.MessagePairs) == 0 {
+					return m, nil
+				}
+				return m, m.regenerate()
+			}
+			// Handle '[' and ']' keys to flip between the focused pair's
+			// responses
+			if len(msg.Runes) == 1 && (msg.Runes[0] == '[' || msg.Runes[0] == ']') && m.Mode == ReadMode {
+				if m.IsWaiting || m.ChatRequested {
+					return m, nil
+				}
+				if msg.Runes[0] == '[' {
+					m.switchResponse(-1)
+				} else {
+					m.switchResponse(1)
+				}
+				return m, nil
+			}
+			// Handle '/' and '?' keys to search forward and backward
+			if len(msg.Runes) == 1 && (msg.Runes[0] == '/' || msg.Runes[0] == '?') && m.Mode == ReadMode {
+				m.openSearch(msg.Runes[0] == '?')
+				return m, nil
+			}
+			// Handle 'n' and 'N' keys to repeat the search in the same or
+			// opposite direction
+			if len(msg.Runes) == 1 && (msg.Runes[0] == 'n' || msg.Runes[0] == 'N') && m.Mode == ReadMode {
+				m.searchNext((msg.Runes[0] == 'n') != m.Search.Backward)
+				return m, nil
+			}
+			// Handle 'e' key to compose a request in the external editor,
+			// sending it when the editor exits
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'e' && m.Mode == ReadMode {
+				if m.IsWaiting || m.ChatRequested {
+					return m, nil
+				}
+				return m, openEditor(m.Textarea.Value(), true)
+			}
+			// Handle 'b' key to list the focused pair's code blocks
+			if len(msg.Runes) == 1 && msg.Runes[0] == 'b' && m.Mode == ReadMode {
+				m.openCodeBlocks()
+				return m, nil
+			}
 			// Handle 'K' key to move to previous message pair
 			if len(msg.Runes) == 1 && msg.Runes[0] == 'K' && m.Mode == ReadMode {
 				if m.CurrentPairIndex > 0 {
@@ -115,64 +262,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Do nothing if already at last message
 				return m, nil
 			}
-		case tea.KeyEnter:
-			if !m.Textarea.Focused() {
-				m.Textarea.Focus()
+		case tea.KeyUp, tea.KeyDown:
+			// Recall earlier prompts
+			if m.Mode == PromptMode && m.recallPrompt(msg.Type == tea.KeyUp) {
 				return m, nil
 			}
-			// Send message
-			input := strings.TrimSpace(m.Textarea.Value())
-			if input == "" {
+		case tea.KeyCtrlR:
+			// Search earlier prompts, starting from the text typed
+			if m.Mode == PromptMode {
+				m.openHistoryPicker(m.Textarea.Value(), m.Config.Input.HistoryIsGlobal())
 				return m, nil
 			}
-			// Handle commands
-			if input == "clear" {
-				m.MessagePairs = []MessagePair{}
-				m.CurrentPairIndex = 0
-				m.Viewport.SetContent("")
-				m.Textarea.Reset()
+		case tea.KeyCtrlX:
+			// Ctrl+X Ctrl+E edits the prompt in the external editor
+			if m.Mode == PromptMode {
+				m.PendingCtrlX = true
 				return m, nil
 			}
-			if input == "exit" || input == "quit" {
-				return m, tea.Quit
+		case tea.KeyCtrlE:
+			if ctrlX && m.Mode == PromptMode {
+				return m, openEditor(m.Textarea.Value(), false)
 			}
-
-			// Create new message pair with request
-			newPair := MessagePair{
-				Request:  input,
-				Response: "", // Will be filled when response arrives
+		case tea.KeyTab:
+			if m.Mode == PromptMode {
+				if !m.completeAttachment() {
+					m.completeCommand()
+				}
+				return m, nil
 			}
-			m.MessagePairs = append(m.MessagePairs, newPair)
-			m.CurrentPairIndex = len(m.MessagePairs) - 1    // Focus on the newly created pair
-			m.ResponseTargetIndex = len(m.MessagePairs) - 1 // Response will go to this index
-
-			m.Textarea.Reset()
-			m.LoadingModel = true
-			m.ModelIsLoaded = false
-			m.LoadingStart = time.Now()
-			m.RequestStart = time.Now() // Track when request was sent
-			m.IsWaiting = false
-			m.ChatRequested = true
-			m.ResponseLines = []string{}
-			m.StreamBuffer = ""
-
-			// Update viewport to show user message immediately
-			m.updateViewport()
-
-			m.Mode = ReadMode
-			m.Textarea.Blur()
-			m.Viewport.Height = m.calculateViewportHeight()
-			ctx, cancelFn := context.WithCancel(context.Background())
-			m.cancelCurrentRequestFn = cancelFn
-
-			// Save the model being used
-			saveLastUsedModel(m.CurrentModel)
-
-			return m, tea.Batch(
-				checkModelStatus(m.CurrentModel),
-				sendChatRequestCmd(m.MessagePairs, m.CurrentModel, m.Send, ctx, cancelFn, m.ChatURL),
-				tickCmd(),
-			)
+		case tea.KeyEnter:
+			if m.Mode == PromptMode {
+				// Not a submit key, so the textarea breaks the line
+				break
+			}
+			if !m.Textarea.Focused() {
+				m.Textarea.Focus()
+				return m, nil
+			}
+			return m.submitPrompt()
 		}
 
 	case tea.WindowSizeMsg:
@@ -216,49 +343,123 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			tickCmds = append(tickCmds, tickCmd())
 		}
 		if m.LoadingModel {
-			tickCmds = append(tickCmds, checkModelStatus(m.CurrentModel))
+			tickCmds = append(tickCmds, checkModelStatus(m.Client, m.CurrentModel))
 		}
 		if len(tickCmds) > 0 {
 			return m, tea.Batch(tickCmds...)
 		}
 
 	case ResponseLineMsg:
-		m.ResponseLines = []string{}
-		m.ResponseLines = append(m.ResponseLines, string(msg))
+		if msg.RequestID != m.RequestID {
+			return m, nil
+		}
+		m.ResponseLines = []string{msg.Text}
 		m.updateViewport()
 
+	case ThinkingLineMsg:
+		if msg.RequestID != m.RequestID {
+			return m, nil
+		}
+		if m.ResponseTargetIndex < len(m.MessagePairs) {
+			m.MessagePairs[m.ResponseTargetIndex].Thinking = msg.Thinking
+			m.updateViewport()
+		}
+
+	case ResponseMetricsMsg:
+		if msg.RequestID != m.RequestID {
+			return m, nil
+		}
+		if m.ResponseTargetIndex < len(m.MessagePairs) {
+			m.MessagePairs[m.ResponseTargetIndex].Metrics = msg.Metrics
+		}
+
 	case ResponseCompleteMsg:
-		// Response received, stop waiting timer
-		m.IsWaiting = false
-		m.ChatRequested = false
+		if msg.RequestID != m.RequestID {
+			// From a cancelled request, already finished by cancelRequest
+			return m, nil
+		}
+		m.finishResponse(msg.Response, nil)
+		return m, m.autoCompact()
 
-		response := string(msg)
-		response = strings.TrimSpace(response)
+	case responseFailedMsg:
+		if msg.requestID != m.RequestID {
+			return m, nil
+		}
+		// Keep what arrived before the failure, with the error on the pair
+		m.finishResponse(msg.response, msg.err)
+		return m, nil
 
-		// Calculate response duration and update the target message pair
-		if len(m.MessagePairs) > 0 && m.ResponseTargetIndex < len(m.MessagePairs) {
-			duration := time.Since(m.RequestStart)
-			m.MessagePairs[m.ResponseTargetIndex].Response = response
-			m.MessagePairs[m.ResponseTargetIndex].Duration = duration
+	case editorFinishedMsg:
+		return m.finishEditing(msg)
+
+	case blockRunMsg:
+		m.showRunOutput(msg)
+
+	case copiedMsg:
+		if msg.err != nil {
+			m.setNotice(fmt.Sprintf("copy failed: %v", msg.err), true)
+		} else {
+			m.setNotice("copied "+msg.what, false)
 		}
 
-		// Switch to ReadMode and blur textarea
-		m.Mode = ReadMode
-		m.Textarea.Blur()
+	case compactedMsg:
+		m.applyCompaction(msg)
 
-		// Update viewport to show full conversation
-		m.Viewport.Height = m.calculateViewportHeight()
-		m.updateViewport()
+	case modelListMsg:
+		if msg.err != nil {
+			m.pickerFailed(ModelPickerMode, msg.err)
+			return m, nil
+		}
+		m.InstalledModels = nil
+		for _, model := range msg.local {
+			m.InstalledModels = append(m.InstalledModels, model.Name)
+		}
+		if m.Mode == ModelPickerMode {
+			m.Picker.SetItems(modelPickerItems(msg.local, msg.running, m.CurrentModel))
+		}
+
+	case sessionListMsg:
+		if msg.err != nil {
+			m.pickerFailed(SessionPickerMode, msg.err)
+			return m, nil
+		}
+		if m.Mode == SessionPickerMode {
+			m.Picker.SetItems(sessionPickerItems(msg.sessions, m.Session.ID))
+		}
+
+	case sessionSearchMsg:
+		if msg.err != nil {
+			m.pickerFailed(SessionSearchMode, msg.err)
+			return m, nil
+		}
+		if m.Mode == SessionSearchMode {
+			m.Picker.SetItems(sessionSearchItems(msg.matches, m.Session.ID))
+		}
 
 	case modelSelectedMsg:
+		if m.CurrentModel != "" {
+			// Chosen while the running model was looked up
+			return m, nil
+		}
 		m.CurrentModel = msg.model
 		saveLastUsedModel(m.CurrentModel)
 		// Don't set modelIsLoaded - let modelStatusMsg handle that
+		return m, contextSizeCmd(m.Client, m.CurrentModel)
 
 	case modelLoadedMsg:
+		if m.CurrentModel != "" && m.CurrentModel != msg.model {
+			return m, nil
+		}
 		m.ModelIsLoaded = true
 		m.CurrentModel = msg.model
 		saveLastUsedModel(m.CurrentModel)
+		return m, contextSizeCmd(m.Client, m.CurrentModel)
+
+	case contextSizeMsg:
+		if msg.model == m.CurrentModel {
+			m.ContextLength = msg.numCtx
+			m.updateViewport()
+		}
 
 	case modelStatusMsg:
 		m.ModelIsLoaded = msg.loaded
@@ -272,31 +473,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// If not loaded yet, keep polling (tickMsg will continue)
 
+	case shellDoneMsg:
+		return m.finishShell(msg)
+
 	case SetSendFuncMsg:
 		m.Send = msg.Send
 
 	case errorMsg:
+		// A failed status check; a pending request ends with its own
+		// responseFailedMsg if Ollama is unreachable
 		m.Err = msg.err
-		m.IsWaiting = false
-		m.LoadingModel = false
 		return m, nil
 	}
 
 	// Only update components based on current mode
 	switch m.Mode {
 	case PromptMode:
+		before := m.Textarea.Value()
 		m.Textarea, cmd = m.Textarea.Update(msg)
-		lines := m.Textarea.LineInfo().Height
-		m.Textarea.SetHeight(lines)
-		//key msg for InputEnd
-		inputStartKeyMsg := tea.KeyMsg{Type: tea.KeyCtrlA}
-		inputEndKeyMsg := tea.KeyMsg{Type: tea.KeyCtrlE}
-		m.Textarea, _ = m.Textarea.Update(inputStartKeyMsg)
-		m.Textarea, _ = m.Textarea.Update(inputEndKeyMsg)
 		cmds = append(cmds, cmd)
+		if m.Textarea.Value() != before {
+			m.forgetOversize()
+		}
 
-		// Recalculate viewport height when textarea height changes
-		m.Viewport.Height = m.calculateViewportHeight()
+		// Grow or shrink the prompt with its text, which also resizes the
+		// viewport
+		m.fitPrompt()
 	case ReadMode:
 		m.Viewport, cmd = m.Viewport.Update(msg)
 		cmds = append(cmds, cmd)
@@ -305,6 +507,166 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// submitPrompt sends the text in the prompt as a new request, or runs it
+// if it is a slash command
+func (m Model) submitPrompt() (tea.Model, tea.Cmd) {
+	input := strings.TrimSpace(m.Textarea.Value())
+	if input == "" {
+		return m, nil
+	}
+	m.recordPrompt(input)
+	// Handle slash commands; "//" sends a literal leading slash
+	if name, args, ok := parseCommand(input); ok {
+		return m.runCommand(name, args)
+	}
+	if strings.HasPrefix(input, "//") {
+		input = input[1:]
+	}
+
+	attachments, missing, err := attachFiles(input)
+	if err != nil {
+		m.setNotice(err.Error(), true)
+		return m, nil
+	}
+	if len(missing) > 0 {
+		m.setNotice(fmt.Sprintf("%s: no such file, sent as text", strings.Join(missing, ", ")), false)
+	}
+	if commands := session.Commands(input); len(commands) > 0 {
+		if m.OversizeConfirmed == input && m.OversizeCommands != nil {
+			// Sent again after the warning, with the output that was too big
+			return m.sendRequest(input, attachments, m.OversizeCommands)
+		}
+		// Warn before running them if the attachments alone are too big
+		if !m.checkRequestSize(input, MessagePair{Request: input, Attachments: attachments}) {
+			return m, nil
+		}
+		// Their output is sent once they are confirmed and have run
+		m.confirmShell(shellRun{Input: input, Commands: commands, Attachments: attachments})
+		return m, nil
+	}
+	return m.sendRequest(input, attachments, nil)
+}
+
+// sendRequest sends input as a new request with the files it attaches and
+// the output of the commands it runs
+func (m Model) sendRequest(input string, attachments []session.Attachment, commands []session.Command) (tea.Model, tea.Cmd) {
+	// Create new message pair with request
+	newPair := MessagePair{
+		Request:     input,
+		Response:    "", // Will be filled when response arrives
+		Model:       m.CurrentModel,
+		RequestedAt: time.Now(),
+		Options:     m.options(),
+		Attachments: attachments,
+		Commands:    commands,
+	}
+	if !m.checkRequestSize(input, newPair) {
+		return m, nil
+	}
+	m.forgetOversize()
+	if m.Editing {
+		// An edited request replaces the rest of the conversation
+		// with a new branch; the old one is kept as a sibling
+		m.branchFrom(m.EditIndex, newPair)
+		m.Editing = false
+	} else {
+		m.MessagePairs = append(m.MessagePairs, newPair)
+	}
+	m.CurrentPairIndex = len(m.MessagePairs) - 1    // Focus on the newly created pair
+	m.ResponseTargetIndex = len(m.MessagePairs) - 1 // Response will go to this index
+
+	m.setPrompt("")
+	return m, m.startRequest()
+}
+
+// finishResponse stores the response to the pending request, and why it
+// failed if it did, then shows it in read mode
+func (m *Model) finishResponse(response string, err error) {
+	// Response received, stop waiting timer
+	m.IsWaiting = false
+	m.ChatRequested = false
+	m.LoadingModel = false
+	m.Regenerating = false
+
+	// Calculate response duration and update the target message pair
+	if len(m.MessagePairs) > 0 && m.ResponseTargetIndex < len(m.MessagePairs) {
+		pair := &m.MessagePairs[m.ResponseTargetIndex]
+		pair.Response = strings.TrimSpace(response)
+		pair.Duration = time.Since(m.RequestStart)
+		if err != nil {
+			pair.Error = err.Error()
+		}
+		m.saveSession()
+	}
+
+	// Switch to ReadMode and blur textarea
+	m.Mode = ReadMode
+	m.Textarea.Blur()
+
+	// Update viewport to show full conversation
+	m.Viewport.Height = m.calculateViewportHeight()
+	m.updateViewport()
+}
+
+// cancelRequest stops the pending request, keeping what was streamed of
+// the response; a cancelled regenerate returns to the earlier response
+func (m *Model) cancelRequest() {
+	m.IsWaiting = false
+	m.ChatRequested = false
+	m.LoadingModel = false
+	if m.cancelCurrentRequestFn != nil {
+		m.cancelCurrentRequestFn()
+		m.cancelCurrentRequestFn = nil
+	}
+	// Messages still streamed for it are dropped
+	m.RequestID++
+
+	if m.Regenerating {
+		m.abandonRegenerate()
+	} else if m.ResponseTargetIndex < len(m.MessagePairs) {
+		pair := &m.MessagePairs[m.ResponseTargetIndex]
+		pair.Cancelled = true
+		pair.Response = strings.TrimSpace(strings.Join(m.ResponseLines, ""))
+		pair.Duration = time.Since(m.RequestStart)
+	}
+	m.ResponseLines = []string{}
+	m.saveSession()
+	m.updateViewport()
+}
+
+// startRequest sends the conversation up to ResponseTargetIndex and waits
+// for the response in read mode
+func (m *Model) startRequest() tea.Cmd {
+	m.LoadingModel = true
+	m.ModelIsLoaded = false
+	m.LoadingStart = time.Now()
+	m.RequestStart = time.Now() // Track when request was sent
+	m.IsWaiting = false
+	m.ChatRequested = true
+	m.ResponseLines = []string{}
+	m.StreamBuffer = ""
+
+	// Update viewport to show user message immediately
+	m.updateViewport()
+
+	m.Mode = ReadMode
+	m.Textarea.Blur()
+	m.Viewport.Height = m.calculateViewportHeight()
+	ctx, cancelFn := context.WithCancel(context.Background())
+	m.cancelCurrentRequestFn = cancelFn
+	m.RequestID++
+
+	// Save the model being used
+	saveLastUsedModel(m.CurrentModel)
+	m.saveSession()
+
+	return tea.Batch(
+		checkModelStatus(m.Client, m.CurrentModel),
+		sendChatRequestCmd(m.newChatRequest(m.MessagePairs[:m.ResponseTargetIndex+1]), m.RequestID, m.Send, ctx, cancelFn, m.Client),
+		tickCmd(),
+	)
+}
+
 // Commands
 func tickCmd() tea.Cmd {
 	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
@@ -312,130 +674,125 @@ func tickCmd() tea.Cmd {
 	})
 }
 
-func sendChatRequestCmd(messagePairs []MessagePair, modelName string, sendFn func(tea.Msg), ctx context.Context, cancelFn func(), chatURL string) tea.Cmd {
-	return func() tea.Msg {
-		// Convert message pairs to Ollama messages
-		var ollamaMessages []OllamaMessage
-		for _, pair := range messagePairs {
-			// Skip cancelled messages - they should not be included in context
-			if pair.Cancelled {
-				continue
-			}
-			ollamaMessages = append(ollamaMessages, OllamaMessage{
-				Role:    "user",
-				Content: pair.Request,
-			})
-			if pair.Response != "" {
-				ollamaMessages = append(ollamaMessages, OllamaMessage{
-					Role:    "assistant",
-					Content: pair.Response,
-				})
-			}
-		}
-
-		reqBody := ChatRequest{
-			Model:    modelName,
-			Messages: ollamaMessages,
-			Stream:   true,
+// buildChatMessages converts message pairs to the Ollama messages sent as
+// conversation context, led by the system prompt if there is one.
+func buildChatMessages(systemPrompt string, messagePairs []MessagePair) []ollama.Message {
+	var ollamaMessages []ollama.Message
+	if systemPrompt != "" {
+		ollamaMessages = append(ollamaMessages, ollama.Message{
+			Role:    "system",
+			Content: systemPrompt,
+		})
+	}
+	for _, pair := range messagePairs {
+		// Skip cancelled and failed messages - they should not be included in context
+		if pair.Cancelled || pair.Error != "" {
+			continue
 		}
-
-		jsonData, err := json.Marshal(reqBody)
-		if err != nil {
-			return errorMsg{err: fmt.Errorf("failed to marshal request: %w", err)}
+		ollamaMessages = append(ollamaMessages, ollama.Message{
+			Role:    "user",
+			Content: pair.Prompt(),
+		})
+		if pair.Response != "" {
+			ollamaMessages = append(ollamaMessages, ollama.Message{
+				Role:    "assistant",
+				Content: pair.Response,
+			})
 		}
+	}
+	return ollamaMessages
+}
 
-		req, err := http.NewRequestWithContext(ctx, "POST", chatURL, bytes.NewBuffer(jsonData))
-		req.Header.Set("Content-Type", "application/json")
-		client := &http.Client{}
+// newChatRequest builds the request for the conversation so far with the
+// current model, system prompt, summary, options and reasoning setting,
+// dropping pairs that do not fit the context window
+func (m Model) newChatRequest(messagePairs []MessagePair) ollama.ChatRequest {
+	req := ollama.ChatRequest{
+		Model:    m.CurrentModel,
+		Messages: buildChatMessages(m.requestSystemPrompt(), m.contextPairs(messagePairs)),
+		Think:    m.think(),
+	}
+	if opts := m.options(); !opts.IsZero() {
+		req.Options = &opts
+	}
+	return req
+}
 
-		resp, err := client.Do(req)
+// sendChatRequestCmd streams the response to req, tagging its messages with
+// the request's id
+func sendChatRequestCmd(req ollama.ChatRequest, id int, sendFn func(tea.Msg), ctx context.Context, cancelFn func(), client *ollama.Client) tea.Cmd {
+	return func() tea.Msg {
+		defer cancelFn()
 
+		chunks, err := client.Chat(ctx, req)
 		if err != nil {
-			return errorMsg{err: fmt.Errorf("failed to send request: %w", err)}
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return errorMsg{err: fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))}
+			if ctx.Err() != nil {
+				// Cancelled before the response started
+				return ResponseCompleteMsg{RequestID: id}
+			}
+			return responseFailedMsg{requestID: id, err: err}
 		}
 
 		// Stream the response
-		scanner := bufio.NewScanner(resp.Body)
-		var fullResponse strings.Builder
-		outChan := make(chan []byte)
-
-		go func() {
-			for scanner.Scan() {
-				outChan <- scanner.Bytes()
-			}
-			cancelFn()
-		}()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return ResponseCompleteMsg(fullResponse.String())
-			case responseBytes := <-outChan:
-				var streamResp StreamResponse
-				copiedResponseBytes := make([]byte, len(responseBytes))
-				copy(copiedResponseBytes, responseBytes)
-				if err := json.Unmarshal(copiedResponseBytes, &streamResp); err != nil {
-					return ResponseCompleteMsg(fullResponse.String())
-				}
-
-				if streamResp.Message.Content != "" {
-					fullResponse.WriteString(streamResp.Message.Content)
-				}
+		var fullResponse, thinking strings.Builder
+		for chunk := range chunks {
+			if chunk.Message.Thinking != "" {
+				thinking.WriteString(chunk.Message.Thinking)
+				sendFn(ThinkingLineMsg{RequestID: id, Thinking: thinking.String()})
+			}
+			if chunk.Message.Content != "" {
+				fullResponse.WriteString(chunk.Message.Content)
 				// Send partial updates for streaming effect
-				sendFn(ResponseLineMsg(fullResponse.String()))
+				sendFn(ResponseLineMsg{RequestID: id, Text: fullResponse.String()})
+			}
+			if chunk.Done {
+				sendFn(ResponseMetricsMsg{RequestID: id, Metrics: chunk.Metrics})
+			}
+			if chunk.Err != nil {
+				return responseFailedMsg{requestID: id, response: fullResponse.String(), err: chunk.Err}
 			}
 		}
+		return ResponseCompleteMsg{RequestID: id, Response: fullResponse.String()}
+	}
+}
+
+// ResolveModel picks the model to chat with: the first model already loaded
+// into memory, otherwise the last model used (or the default model). loaded
+// reports whether the model came from the running models.
+func ResolveModel(ctx context.Context, client *ollama.Client) (model string, loaded bool, err error) {
+	running, err := client.ListRunning(ctx)
+	if err != nil {
+		return "", false, err
 	}
+	if len(running) > 0 {
+		return running[0].Name, true, nil
+	}
+	// No models running, use last used model
+	return loadLastUsedModel(), false, nil
 }
 
-func checkRunningModel() tea.Cmd {
+func checkRunningModel(client *ollama.Client) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := http.Get(ollamaModelsURL)
+		model, loaded, err := ResolveModel(context.Background(), client)
 		if err != nil {
 			return errorMsg{err: err}
 		}
-		defer resp.Body.Close()
-
-		var modelsResp ModelsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
-			return errorMsg{err: err}
-		}
-
-		if len(modelsResp.Models) > 0 {
-			return modelLoadedMsg{model: modelsResp.Models[0].Name}
+		if loaded {
+			return modelLoadedMsg{model: model}
 		}
-
-		// No models running, use last used model
-		lastModel := loadLastUsedModel()
-		if lastModel != "" {
-			return modelSelectedMsg{model: lastModel}
-		}
-
-		return modelSelectedMsg{model: defaultModel}
+		return modelSelectedMsg{model: model}
 	}
 }
 
-func checkModelStatus(modelName string) tea.Cmd {
+func checkModelStatus(client *ollama.Client, modelName string) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := http.Get(ollamaModelsURL)
+		running, err := client.ListRunning(context.Background())
 		if err != nil {
 			return errorMsg{err: err}
 		}
-		defer resp.Body.Close()
-
-		var modelsResp ModelsResponse
-		if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
-			return errorMsg{err: err}
-		}
 
 		// Check if the specific model is loaded
-		for _, m := range modelsResp.Models {
+		for _, m := range running {
 			if m.Name == modelName {
 				return modelStatusMsg{loaded: true}
 			}
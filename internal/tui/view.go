@@ -17,7 +17,11 @@ func (m *Model) calculateViewportHeight() int {
 	if m.IsWaiting || m.ChatRequested {
 		textareaHeight = 1
 		inputBorders = 2
-	} else if m.Mode == ReadMode {
+	} else if m.Mode == SearchMode {
+		textareaHeight = 1
+	} else if m.Mode == ShellConfirmMode {
+		textareaHeight = m.Shell.viewHeight()
+	} else if m.Mode != PromptMode {
 		textareaHeight = 0
 		inputBorders = 0
 	}
@@ -25,14 +29,52 @@ func (m *Model) calculateViewportHeight() int {
 }
 
 func (m *Model) updateViewport() {
+	m.Viewport.SetContent(m.highlightMatches(m.renderPair(m.CurrentPairIndex), m.CurrentPairIndex))
+}
+
+// renderPair draws pair i as the viewport shows it, led by the system
+// prompt and summary where they belong
+func (m *Model) renderPair(i int) string {
 	var content strings.Builder
+	if m.markdown == nil {
+		m.markdown = &markdownCache{}
+	}
+
+	// System prompt header above the first message pair
+	if systemPrompt := m.systemPrompt(); systemPrompt != "" && i == 0 {
+		content.WriteString(m.renderSection("System", 's', systemPrompt, m.SystemPromptExpanded))
+	}
 
-	// Display only the current message pair
-	if len(m.MessagePairs) > 0 && m.CurrentPairIndex < len(m.MessagePairs) {
-		pair := m.MessagePairs[m.CurrentPairIndex]
+	// Display the message pair
+	if len(m.MessagePairs) > 0 && i < len(m.MessagePairs) {
+		pair := m.MessagePairs[i]
+
+		// Mark pairs left out of requests, by /compact or to fit the
+		// context window
+		if pair.Summarized {
+			content.WriteString(lipgloss.NewStyle().
+				Foreground(lipgloss.Color("240")).
+				Render("⋯ Summarized: replaced by the conversation summary in requests"))
+			content.WriteString("\n")
+		} else if sent, _ := m.contextPlan(m.MessagePairs); !sent[i] && !pair.Cancelled && pair.Error == "" {
+			content.WriteString(lipgloss.NewStyle().
+				Foreground(lipgloss.Color("214")).
+				Render("⋯ Outside the context window: no longer sent to the model"))
+			content.WriteString("\n")
+		}
+
+		// The summary leads the first pair still sent in full
+		if summary := m.summary(); summary != "" && !pair.Summarized &&
+			(i == 0 || m.MessagePairs[i-1].Summarized) {
+			content.WriteString(m.renderSection("Summary", 's', summary, m.SystemPromptExpanded))
+		}
 
 		// Request message with border (straight line)
 		requestBorderText := "──── Request "
+		if len(pair.Attachments) > 0 {
+			// The files are sent with the request but not shown
+			requestBorderText += "(attached " + attachmentNames(pair) + ") "
+		}
 		remainingWidth := max(m.Viewport.Width-utf8.RuneCountInString(requestBorderText), 0)
 		requestBorder := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240")).
@@ -43,55 +85,46 @@ func (m *Model) updateViewport() {
 		content.WriteString(pair.Request)
 		content.WriteString("\n\n")
 
+		// Output of the request's ! commands, sent in place of their lines
+		content.WriteString(m.renderCommands(pair))
+
+		// Reasoning from thinking models, between the request and response
+		if pair.Thinking != "" {
+			content.WriteString(m.renderSection("Thinking", 't', pair.Thinking, m.ThinkingExpanded))
+		}
+
 		// Response message (if present)
 		if pair.Response != "" {
-			// Response border with duration (straight line)
-			var responseBorderText string
-			if pair.Cancelled {
-				responseBorderText = "──── Response (cancelled) "
-			} else {
-				durationStr := fmt.Sprintf("%.1fs", pair.Duration.Seconds())
-				responseBorderText = fmt.Sprintf("──── Response (%s) ", durationStr)
-			}
-			remainingWidth := max(m.Viewport.Width-utf8.RuneCountInString(responseBorderText), 0)
-			responseBorder := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("240")).
-				Render(responseBorderText + strings.Repeat("─", remainingWidth))
+			// Response border with duration and statistics (straight line)
+			responseBorder := m.renderResponseBorder(pair)
 
 			content.WriteString(responseBorder)
 			content.WriteString("\n")
 
 			// Render response as markdown
-			rendered, err := m.Renderer.Render(pair.Response)
+			rendered, err := m.markdown.render(m.Renderer, pair.Response)
 			if err != nil {
 				content.WriteString(pair.Response)
 			} else {
 				content.WriteString(rendered)
 			}
 			content.WriteString("\n")
+			content.WriteString(renderPairError(pair))
 		} else {
 			// Response border without duration (straight line)
-			var responseBorderText string
-			if pair.Cancelled {
-				responseBorderText = "──── Response (cancelled) "
-			} else {
-				responseBorderText = "──── Response "
-			}
-			remainingWidth := max(m.Viewport.Width-utf8.RuneCountInString(responseBorderText), 0)
-			responseBorder := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("240")).
-				Render(responseBorderText + strings.Repeat("─", remainingWidth))
+			responseBorder := m.renderResponseBorder(pair)
 			content.WriteString(responseBorder)
 			content.WriteString("\n")
 
 			partialResponse := strings.Builder{}
 			if pair.Cancelled {
 				content.WriteString("Request cancelled\n")
-			} else if len(m.ResponseLines) > 0 && m.CurrentPairIndex == m.ResponseTargetIndex {
+			} else if pair.Error != "" {
+				content.WriteString(renderPairError(pair))
+			} else if len(m.ResponseLines) > 0 && i == m.ResponseTargetIndex {
 				// Only show partial response if viewing the message that's receiving it
-				partialResponse.WriteString("\n")
 				partialResponse.WriteString(strings.Join(m.ResponseLines, ""))
-				rendered, err := m.Renderer.Render(partialResponse.String())
+				rendered, err := m.markdown.renderStreaming(m.Renderer, partialResponse.String())
 				if err != nil {
 					content.WriteString(partialResponse.String())
 				} else {
@@ -103,7 +136,109 @@ func (m *Model) updateViewport() {
 		}
 	}
 
-	m.Viewport.SetContent(content.String())
+	return content.String()
+}
+
+// renderResponseBorder draws the line above a response with its duration,
+// token statistics and model, warning when it was cut off by a length limit
+func (m *Model) renderResponseBorder(pair MessagePair) string {
+	var details []string
+	if pair.Cancelled {
+		details = append(details, "cancelled")
+	} else if pair.Error != "" {
+		details = append(details, "failed")
+	} else if pair.Response != "" {
+		details = append(details, fmt.Sprintf("%.1fs", pair.Duration.Seconds()))
+	}
+	if stats := pair.Metrics; stats.EvalCount > 0 {
+		details = append(details, fmt.Sprintf("%d tokens", stats.EvalCount))
+		if tps := stats.TokensPerSecond(); tps > 0 {
+			details = append(details, fmt.Sprintf("%.1f tok/s", tps))
+		}
+		// Loads under this are a model already in memory
+		if stats.LoadDuration >= 500*time.Millisecond {
+			details = append(details, fmt.Sprintf("load %.1fs", stats.LoadDuration.Seconds()))
+		}
+	}
+
+	borderText := "──── Response "
+	if len(details) > 0 {
+		borderText += "(" + strings.Join(details, ", ") + ") "
+	}
+	if pair.Model != "" {
+		borderText += fmt.Sprintf("· %s ", pair.Model)
+	}
+	var warning string
+	if pair.Metrics.Truncated() {
+		warning = "⚠ truncated at length limit "
+	}
+	remainingWidth := max(m.Viewport.Width-utf8.RuneCountInString(borderText+warning), 0)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	return dimStyle.Render(borderText) +
+		lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render(warning) +
+		dimStyle.Render(strings.Repeat("─", remainingWidth))
+}
+
+// renderPairError explains why a response failed, or is empty if it did not
+func renderPairError(pair MessagePair) string {
+	if pair.Error == "" {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196")).
+		Render("⚠ Response failed: "+pair.Error) + "\n"
+}
+
+// tokenSummary totals the tokens read and generated across the session
+func (m Model) tokenSummary() string {
+	var in, out int
+	for _, pair := range m.MessagePairs {
+		in += pair.Metrics.PromptEvalCount
+		out += pair.Metrics.EvalCount
+	}
+	if in == 0 && out == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Tokens: %s in / %s out", formatCount(in), formatCount(out))
+}
+
+// formatCount abbreviates thousands, e.g. 12345 as 12.3k
+func formatCount(n int) string {
+	if n < 1000 {
+		return fmt.Sprint(n)
+	}
+	return fmt.Sprintf("%.1fk", float64(n)/1000)
+}
+
+// renderSection draws dimmed text such as the system prompt under a titled
+// border, collapsed to its first line unless expanded with key
+func (m *Model) renderSection(title string, key rune, text string, expanded bool) string {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	borderText := fmt.Sprintf("──── %s (%c to expand) ", title, key)
+	if expanded {
+		borderText = fmt.Sprintf("──── %s (%c to collapse) ", title, key)
+	}
+	remainingWidth := max(m.Viewport.Width-utf8.RuneCountInString(borderText), 0)
+
+	var b strings.Builder
+	b.WriteString(dimStyle.Render(borderText + strings.Repeat("─", remainingWidth)))
+	b.WriteString("\n")
+	if expanded {
+		b.WriteString(dimStyle.Width(m.Viewport.Width).Render(strings.TrimSpace(text)))
+	} else {
+		firstLine, _, more := strings.Cut(strings.TrimSpace(text), "\n")
+		if runes := []rune(firstLine); len(runes) > m.Viewport.Width-2 {
+			firstLine = string(runes[:max(m.Viewport.Width-2, 0)])
+			more = true
+		}
+		if more {
+			firstLine += " …"
+		}
+		b.WriteString(dimStyle.Render(firstLine))
+	}
+	b.WriteString("\n\n")
+	return b.String()
 }
 
 func (m Model) View() string {
@@ -138,8 +273,13 @@ func (m Model) View() string {
 	b.WriteString(contentStyle.Render(topLine))
 	b.WriteString("\n")
 
-	// Viewport with left padding
+	// Viewport with left padding, or the picker overlay in its place
 	viewportContent := m.Viewport.View()
+	if m.isPickerMode() {
+		viewportContent = m.Picker.View(effectiveWidth, m.Viewport.Height)
+	} else if m.Mode == CodeBlockMode {
+		viewportContent = m.Blocks.View(effectiveWidth, m.Viewport.Height)
+	}
 	b.WriteString(contentStyle.Render(viewportContent))
 	b.WriteString("\n\n")
 
@@ -166,6 +306,24 @@ func (m Model) View() string {
 			Render(textareaView)
 		b.WriteString(contentStyle.Render(textareaStyled))
 		b.WriteString("\n")
+	} else if m.Mode == SearchMode {
+		searchStyled := lipgloss.NewStyle().
+			Width(effectiveWidth).
+			Border(lipgloss.NormalBorder(), true, false, true, false).
+			BorderForeground(lipgloss.Color("240")).
+			Padding(0, 1).
+			Render(m.Search.Input.View())
+		b.WriteString(contentStyle.Render(searchStyled))
+		b.WriteString("\n")
+	} else if m.Mode == ShellConfirmMode {
+		shellStyled := lipgloss.NewStyle().
+			Width(effectiveWidth).
+			Border(lipgloss.NormalBorder(), true, false, true, false).
+			BorderForeground(lipgloss.Color("240")).
+			Padding(0, 1).
+			Render(m.Shell.View())
+		b.WriteString(contentStyle.Render(shellStyled))
+		b.WriteString("\n")
 	}
 
 	// Bottom: Status line with Model, MSG count, and Timer (centered)
@@ -195,6 +353,26 @@ func (m Model) View() string {
 	var statusParts []string
 	statusParts = append(statusParts, modelStatus)
 	statusParts = append(statusParts, msgCount)
+	if m.Editing {
+		statusParts = append(statusParts, fmt.Sprintf("Editing MSG %d", m.EditIndex+1))
+	} else if branch := m.branchStatus(); branch != "" {
+		statusParts = append(statusParts, branch)
+	}
+	if response := m.responseStatus(); response != "" {
+		statusParts = append(statusParts, response)
+	}
+	if search := m.searchStatus(); search != "" {
+		statusParts = append(statusParts, search)
+	}
+	if gauge := m.contextGauge(); gauge != "" {
+		statusParts = append(statusParts, gauge)
+	}
+	if tokens := m.tokenSummary(); tokens != "" {
+		statusParts = append(statusParts, tokens)
+	}
+	if opts := m.optionsSummary(); opts != "" {
+		statusParts = append(statusParts, opts)
+	}
 	if timerStr != "" {
 		statusParts = append(statusParts, timerStr)
 	}
@@ -202,6 +380,14 @@ func (m Model) View() string {
 	statusLine := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
 		Render(strings.Join(statusParts, " • "))
+	if m.Notice != "" {
+		noticeColor := lipgloss.Color("245")
+		if m.NoticeIsError {
+			noticeColor = lipgloss.Color("196")
+		}
+		statusLine += lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(" • ") +
+			lipgloss.NewStyle().Foreground(noticeColor).Render(m.Notice)
+	}
 
 	b.WriteString(contentStyle.Render(statusLine))
 
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"tama/internal/config"
+	"tama/internal/ollama"
+	"tama/internal/session"
 	"tama/internal/tui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,23 +15,118 @@ import (
 
 var TamaVersion = "0.1.1"
 
+var (
+	hostFlag     string
+	resumeFlag   string
+	continueFlag bool
+	systemFlag   string
+)
+
+// optionFlags hold the generation option flags shared by tama and tama ask
+var optionFlags struct {
+	temperature float64
+	topP        float64
+	numCtx      int
+	seed        int
+	stop        []string
+	think       string
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "tama",
 	Short: "An interactive Ollama REPL",
 	Long:  `Tama is an interactive REPL for chatting with Ollama models.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		runTUI()
+	// Errors are reported by main
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		m := tui.InitialModel()
+		m.Client = newClient(cfg)
+		m.Config = cfg
+		if resumeFlag != "" || continueFlag {
+			s, err := loadResumeSession(resumeFlag)
+			if err != nil {
+				return err
+			}
+			m.LoadSession(s)
+		}
+		if systemFlag != "" {
+			m.SystemPrompt = systemFlag
+		}
+		m.Options = m.Options.Merge(flagOptions(cmd))
+		if optionFlags.think != "" {
+			m.Think, err = ollama.ParseThink(optionFlags.think)
+			if err != nil {
+				return err
+			}
+		}
+		return runTUI(m)
 	},
 }
 
 func init() {
 	rootCmd.Version = TamaVersion
+	rootCmd.PersistentFlags().StringVar(&hostFlag, "host", "", "Ollama server address (overrides $OLLAMA_HOST and the config file)")
+	rootCmd.PersistentFlags().Float64Var(&optionFlags.temperature, "temperature", 0, "Sampling temperature")
+	rootCmd.PersistentFlags().Float64Var(&optionFlags.topP, "top-p", 0, "Nucleus sampling probability")
+	rootCmd.PersistentFlags().IntVar(&optionFlags.numCtx, "num-ctx", 0, "Context window size in tokens")
+	rootCmd.PersistentFlags().IntVar(&optionFlags.seed, "seed", 0, "Random seed, for reproducible responses")
+	rootCmd.PersistentFlags().StringArrayVar(&optionFlags.stop, "stop", nil, "Stop sequence (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&optionFlags.think, "think", "", "Reasoning for thinking models: true, false, low, medium or high")
+	rootCmd.Flags().StringVar(&resumeFlag, "resume", "", "Resume the saved session with this ID")
+	rootCmd.Flags().BoolVarP(&continueFlag, "continue", "c", false, "Resume the most recent saved session")
+	rootCmd.MarkFlagsMutuallyExclusive("resume", "continue")
+	rootCmd.Flags().StringVar(&systemFlag, "system", "", "System prompt for the conversation (overrides the configured default)")
+}
+
+// newClient builds an Ollama client for the host chosen by the --host flag,
+// OLLAMA_HOST or the config file.
+func newClient(cfg config.Config) *ollama.Client {
+	return ollama.NewClient(ollama.ParseHost(cfg.ResolveHost(hostFlag)))
 }
 
-func runTUI() {
+// flagOptions returns the generation options given on the command line.
+// Flags left unset leave the option to the config file or the model.
+func flagOptions(cmd *cobra.Command) ollama.Options {
+	var opts ollama.Options
+	flags := cmd.Flags()
+	if flags.Changed("temperature") {
+		opts.Temperature = &optionFlags.temperature
+	}
+	if flags.Changed("top-p") {
+		opts.TopP = &optionFlags.topP
+	}
+	if flags.Changed("num-ctx") {
+		opts.NumCtx = &optionFlags.numCtx
+	}
+	if flags.Changed("seed") {
+		opts.Seed = &optionFlags.seed
+	}
+	if flags.Changed("stop") {
+		opts.Stop = optionFlags.stop
+	}
+	return opts
+}
+
+// loadResumeSession loads the session named by --resume, or the latest one
+// for --continue
+func loadResumeSession(id string) (*session.Session, error) {
+	store := session.NewStore(session.DefaultDir())
+	if id == "" {
+		return store.Latest()
+	}
+	return store.Load(id)
+}
+
+func runTUI(m tui.Model) error {
 	p := tea.NewProgram(
-		tui.InitialModel(),
+		m,
 		tea.WithAltScreen(),
+		tea.WithOutput(tui.Output),
 		tea.WithMouseCellMotion(),
 		tea.WithReportFocus(),
 	)
@@ -38,10 +136,8 @@ func runTUI() {
 		p.Send(tui.SetSendFuncMsg{Send: p.Send})
 	}()
 
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
+	_, err := p.Run()
+	return err
 }
 
 func main() {
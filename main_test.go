@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseRootFlags parses args as the flags of tama, resetting them after the
+// test
+func parseRootFlags(t *testing.T, args ...string) {
+	t.Helper()
+	t.Cleanup(func() {
+		resumeFlag, continueFlag = "", false
+		rootCmd.Flags().Lookup("resume").Changed = false
+		rootCmd.Flags().Lookup("continue").Changed = false
+	})
+	require.NoError(t, rootCmd.ParseFlags(args))
+	require.NoError(t, rootCmd.ValidateFlagGroups())
+}
+
+func TestResumeTakesSessionID(t *testing.T) {
+	parseRootFlags(t, "--resume", "20260101-000000-abcd")
+
+	assert.Equal(t, "20260101-000000-abcd", resumeFlag)
+	assert.False(t, continueFlag)
+	assert.Empty(t, rootCmd.Flags().Args(), "The ID is not left as a command")
+}
+
+func TestContinueResumesLatest(t *testing.T) {
+	parseRootFlags(t, "-c")
+
+	assert.True(t, continueFlag)
+	assert.Empty(t, resumeFlag)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"tama/internal/session"
+
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search saved sessions",
+	Long: `Search lists the requests and responses of saved sessions that contain
+every word of the query, ignoring case, newest session first.
+
+Each match shows the session ID, which can be reopened with
+tama --resume <id>, and the number of the matching message. Matches on
+branches left by editing a request, or in responses replaced by
+regenerating one, are marked; /search in tama opens them.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store := session.NewStore(session.DefaultDir())
+		return runSearch(store, strings.Join(args, " "), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+}
+
+// runSearch writes the pairs of saved sessions matching query to out
+func runSearch(store *session.Store, query string, out io.Writer) error {
+	matches, err := store.Search(query)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Fprintf(out, "No sessions match %q\n", query)
+		return nil
+	}
+	for _, match := range matches {
+		s := match.Session
+		details := []string{s.ID, fmt.Sprintf("MSG %d", match.Pair+1), s.UpdatedAt.Format("Jan 2 2006 15:04")}
+		if match.Model != "" {
+			details = append(details, match.Model)
+		}
+		if other := match.Other(); other != "" {
+			details = append(details, "("+other+")")
+		}
+		fmt.Fprintf(out, "%s  %s\n    %s\n", strings.Join(details, "  "), s.Title(), match.Snippet)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"tama/internal/session"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchListsMatchingPairs(t *testing.T) {
+	store := session.NewStore(t.TempDir())
+	s := session.New()
+	s.Name = "Concurrency"
+	s.Pairs = []session.Pair{
+		{Request: "Hi", Response: "Hello"},
+		{Request: "What is a goroutine?", Response: "A lightweight thread.", Model: "qwen3:8b"},
+	}
+	require.NoError(t, store.Save(s))
+	var out bytes.Buffer
+
+	require.NoError(t, runSearch(store, "goroutine", &out))
+
+	assert.Contains(t, out.String(), s.ID+"  MSG 2  ")
+	assert.Contains(t, out.String(), "qwen3:8b  Concurrency\n")
+	assert.Contains(t, out.String(), "    What is a goroutine? A lightweight thread.\n")
+}
+
+func TestSearchWithoutMatches(t *testing.T) {
+	var out bytes.Buffer
+
+	require.NoError(t, runSearch(session.NewStore(t.TempDir()), "nothing", &out))
+
+	assert.Equal(t, "No sessions match \"nothing\"\n", out.String())
+}
+
+func TestSearchMarksOtherResponses(t *testing.T) {
+	store := session.NewStore(t.TempDir())
+	s := session.New()
+	s.Pairs = []session.Pair{{
+		Request: "Explain", Response: "Goroutines are cheap",
+		Alternatives: []session.Alternative{{Response: "Guard it with a mutex", Model: "llama3.2:3b"}},
+	}}
+	require.NoError(t, store.Save(s))
+	var out bytes.Buffer
+
+	require.NoError(t, runSearch(store, "mutex", &out))
+
+	assert.Contains(t, out.String(), "llama3.2:3b  (other response)  ")
+}